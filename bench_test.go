@@ -1,6 +1,9 @@
 package gltf
 
-import "testing"
+import (
+	"bytes"
+	"testing"
+)
 
 func BenchmarkOpenASCII(b *testing.B) {
 	benchs := []struct {
@@ -70,3 +73,49 @@ func BenchmarkOpenBinary(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkDecoder_Reset(b *testing.B) {
+	benchs := []struct {
+		name string
+	}{
+		{"testdata/Triangle/glTF-Embedded/Triangle.gltf"},
+		{"testdata/BoxVertexColors/glTF-Binary/BoxVertexColors.glb"},
+	}
+	for _, bb := range benchs {
+		data := readFile(bb.name)
+		b.Run(bb.name+"/new", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := NewDecoder(bytes.NewReader(data), nil).Decode(new(Document)); err != nil {
+					b.Fatalf("Decoder.Decode() error = %v", err)
+				}
+			}
+		})
+		b.Run(bb.name+"/reset", func(b *testing.B) {
+			b.ReportAllocs()
+			r := bytes.NewReader(data)
+			d := NewDecoder(r, nil)
+			for i := 0; i < b.N; i++ {
+				r.Reset(data)
+				d.Reset(r, nil)
+				if err := d.Decode(new(Document)); err != nil {
+					b.Fatalf("Decoder.Decode() error = %v", err)
+				}
+			}
+		})
+		b.Run(bb.name+"/release", func(b *testing.B) {
+			b.ReportAllocs()
+			r := bytes.NewReader(data)
+			d := NewDecoder(r, nil)
+			for i := 0; i < b.N; i++ {
+				r.Reset(data)
+				d.Reset(r, nil)
+				doc := new(Document)
+				if err := d.Decode(doc); err != nil {
+					b.Fatalf("Decoder.Decode() error = %v", err)
+				}
+				d.Release(doc)
+			}
+		})
+	}
+}
@@ -180,8 +180,4 @@ type glbHeader struct {
 	JSONHeader chunkHeader
 }
 
-const (
-	mimetypeApplicationOctet = "data:application/octet-stream;base64"
-	mimetypeImagePNG         = "data:image/png;base64"
-	mimetypeImageJPG         = "data:image/jpeg;base64"
-)
+const mimetypeApplicationOctet = "application/octet-stream"
@@ -0,0 +1,235 @@
+package gltf
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// A Layout defines where the buffers and images of a document are stored.
+type Layout uint8
+
+const (
+	// LayoutSeparate stores each buffer and image in its own external file.
+	LayoutSeparate Layout = iota
+	// LayoutEmbedded stores each buffer and image in the JSON content as a data URI.
+	LayoutEmbedded
+	// LayoutBinary merges all the buffers into a single buffer, to be saved as the BIN chunk of a GLB file.
+	LayoutBinary
+)
+
+// ConvertOptions configures the conversion of a document to a Layout.
+type ConvertOptions struct {
+	// Images also moves the images between buffer views and URIs:
+	// LayoutBinary stores the external and embedded images in buffer views of the merged buffer
+	// while the other layouts move the images out of their buffer views, which are kept.
+	// Without it only the images with a URI are converted, between external files and data URIs.
+	Images bool
+	// Name is the base name of the files created by LayoutSeparate,
+	// such as "model" for "model.bin", "model1.bin" and "model_image0.png".
+	// Defaults to "buffer".
+	Name string
+}
+
+// Convert changes the layout of doc, loading the buffers and images that are not loaded yet.
+// The external resources keep their URIs when converting to LayoutSeparate.
+// Images without data, such as the ones pointing to a remote URL, are left untouched.
+// A nil opts is equivalent to a zero ConvertOptions.
+func Convert(doc *Document, layout Layout, opts *ConvertOptions) error {
+	return ConvertContext(context.Background(), doc, layout, opts)
+}
+
+// ConvertContext is like Convert but aborts loading the resources as soon as ctx is done.
+func ConvertContext(ctx context.Context, doc *Document, layout Layout, opts *ConvertOptions) error {
+	if opts == nil {
+		opts = new(ConvertOptions)
+	}
+	for i := range doc.Buffers {
+		if _, err := bufferData(ctx, doc, i); err != nil {
+			return err
+		}
+	}
+	for i := range doc.Images {
+		if err := loadImage(ctx, doc, i, opts.Images); err != nil {
+			return err
+		}
+	}
+	switch layout {
+	case LayoutSeparate:
+		convertSeparate(doc, opts)
+	case LayoutEmbedded:
+		convertEmbedded(doc, opts)
+	case LayoutBinary:
+		convertBinary(doc, opts)
+	default:
+		return fmt.Errorf("gltf: Unknown layout %d", layout)
+	}
+	return nil
+}
+
+// bufferData loads the buffer and checks that it holds byteLength bytes.
+func bufferData(ctx context.Context, doc *Document, index int) ([]uint8, error) {
+	buffer := &doc.Buffers[index]
+	data, err := buffer.LoadContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < int(buffer.ByteLength) {
+		return nil, &ByteLengthError{Path: indexPath("buffers", index), URI: buffer.URI, ByteLength: buffer.ByteLength, Length: int64(len(data))}
+	}
+	buffer.Data = data[:buffer.ByteLength]
+	return buffer.Data, nil
+}
+
+// loadImage loads the image data and, if withBufferView is true,
+// copies the data of the images stored in a buffer view.
+func loadImage(ctx context.Context, doc *Document, index int, withBufferView bool) error {
+	image := &doc.Images[index]
+	if _, err := image.LoadContext(ctx); err != nil {
+		return err
+	}
+	if image.URI == "" && image.Data == nil && withBufferView {
+		data, err := imageBufferViewData(ctx, doc, indexPath("images", index), image.BufferView)
+		if err != nil {
+			return err
+		}
+		image.Data = data
+	}
+	return nil
+}
+
+func convertSeparate(doc *Document, opts *ConvertOptions) {
+	name := opts.Name
+	if name == "" {
+		name = "buffer"
+	}
+	used := make(map[string]bool)
+	for _, buffer := range doc.Buffers {
+		used[buffer.URI] = true
+	}
+	for _, image := range doc.Images {
+		used[image.URI] = true
+	}
+	// unique returns base+ext, or base_1+ext, base_2+ext... if it is already used.
+	unique := func(base, ext string) string {
+		uri := base + ext
+		for i := 1; used[uri]; i++ {
+			uri = fmt.Sprintf("%s_%d%s", base, i, ext)
+		}
+		used[uri] = true
+		return uri
+	}
+	for i := range doc.Buffers {
+		buffer := &doc.Buffers[i]
+		if buffer.URI != "" && !buffer.IsEmbeddedResource() {
+			continue
+		}
+		if i == 0 {
+			buffer.URI = unique(name, ".bin")
+		} else {
+			buffer.URI = unique(fmt.Sprintf("%s%d", name, i), ".bin")
+		}
+	}
+	for i := range doc.Images {
+		image := &doc.Images[i]
+		if len(image.Data) == 0 || (image.URI == "" && !opts.Images) || (image.URI != "" && !image.IsEmbeddedResource()) {
+			continue
+		}
+		image.MimeType = imageMimeType(image)
+		ext := ".bin"
+		switch image.MimeType {
+		case "image/png":
+			ext = ".png"
+		case "image/jpeg":
+			ext = ".jpg"
+		}
+		image.URI = unique(fmt.Sprintf("%s_image%d", name, i), ext)
+		image.BufferView = 0
+	}
+}
+
+func convertEmbedded(doc *Document, opts *ConvertOptions) {
+	for i := range doc.Buffers {
+		doc.Buffers[i].EmbeddedResource()
+	}
+	for i := range doc.Images {
+		image := &doc.Images[i]
+		if len(image.Data) == 0 || (image.URI == "" && !opts.Images) || image.IsEmbeddedResource() {
+			continue
+		}
+		image.MimeType = imageMimeType(image)
+		image.EmbeddedResource()
+		image.BufferView = 0
+	}
+}
+
+// convertBinary merges the buffers, each one aligned to 4 bytes, and moves the buffer views accordingly.
+// The merged buffer keeps the name, extensions and extras of the first buffer.
+func convertBinary(doc *Document, opts *ConvertOptions) {
+	var size int64
+	for _, buffer := range doc.Buffers {
+		size = paddedLength(size) + int64(len(buffer.Data))
+	}
+	moveImage := func(image *Image) bool {
+		return opts.Images && image.URI != "" && len(image.Data) > 0
+	}
+	for i := range doc.Images {
+		if moveImage(&doc.Images[i]) {
+			size = paddedLength(size) + int64(len(doc.Images[i].Data))
+		}
+	}
+	if len(doc.Buffers) == 0 && size == 0 {
+		return
+	}
+
+	data := make([]uint8, 0, size)
+	offsets := make([]uint32, len(doc.Buffers))
+	for i, buffer := range doc.Buffers {
+		data, offsets[i] = appendAligned(data, buffer.Data)
+	}
+	for i := range doc.BufferViews {
+		view := &doc.BufferViews[i]
+		if view.Buffer >= 0 && int(view.Buffer) < len(offsets) {
+			view.ByteOffset += offsets[view.Buffer]
+			view.Buffer = 0
+		}
+	}
+	for i := range doc.Images {
+		image := &doc.Images[i]
+		if !moveImage(image) {
+			continue
+		}
+		var offset uint32
+		data, offset = appendAligned(data, image.Data)
+		image.MimeType = imageMimeType(image)
+		image.URI = ""
+		image.BufferView = uint32(len(doc.BufferViews))
+		doc.BufferViews = append(doc.BufferViews, BufferView{ByteOffset: offset, ByteLength: uint32(len(image.Data))})
+	}
+	buffer := Buffer{ByteLength: uint32(len(data)), Data: data}
+	if len(doc.Buffers) > 0 {
+		buffer.Name, buffer.Extensions, buffer.Extras = doc.Buffers[0].Name, doc.Buffers[0].Extensions, doc.Buffers[0].Extras
+	}
+	doc.Buffers = []Buffer{buffer}
+}
+
+// appendAligned appends p to data after padding data to 4 bytes and returns the offset of p.
+func appendAligned(data, p []uint8) ([]uint8, uint32) {
+	var padding [3]uint8
+	data = append(data, padding[:paddedLength(int64(len(data)))-int64(len(data))]...)
+	return append(data, p...), uint32(len(data))
+}
+
+// imageMimeType returns the media type of the image,
+// which is the one of its data URI or the one detected from its data if MimeType is empty.
+func imageMimeType(image *Image) string {
+	if image.MimeType != "" {
+		return image.MimeType
+	}
+	if image.IsEmbeddedResource() {
+		if d, err := ParseDataURI(image.URI); err == nil && d.MediaType != "text/plain" {
+			return d.MediaType
+		}
+	}
+	return http.DetectContentType(image.Data)
+}
@@ -0,0 +1,165 @@
+package gltf
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-test/deep"
+)
+
+// convertMemory converts doc to layout, saves it in memory and opens it again.
+func convertMemory(t *testing.T, doc *Document, layout Layout, opts *ConvertOptions) (*Document, memWriteFS) {
+	t.Helper()
+	if err := Convert(doc, layout, opts); err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	name := "a.gltf"
+	if layout == LayoutBinary {
+		name = "a.glb"
+	}
+	fsys := make(memWriteFS)
+	if err := SaveFS(fsys, doc, name, layout == LayoutBinary); err != nil {
+		t.Fatalf("SaveFS() error = %v", err)
+	}
+	mfs := make(fstest.MapFS)
+	for name, b := range fsys {
+		mfs[name] = &fstest.MapFile{Data: b.Bytes()}
+	}
+	got, err := OpenFS(mfs, name)
+	if err != nil {
+		t.Fatalf("OpenFS() error = %v", err)
+	}
+	return got, fsys
+}
+
+func TestConvert_testdata(t *testing.T) {
+	variants := map[Layout]string{
+		LayoutSeparate: "glTF/%s.gltf",
+		LayoutEmbedded: "glTF-Embedded/%s.gltf",
+		LayoutBinary:   "glTF-Binary/%s.glb",
+	}
+	models := []struct {
+		name    string
+		layouts []Layout
+	}{
+		{"BoxVertexColors", []Layout{LayoutSeparate, LayoutEmbedded, LayoutBinary}},
+		{"OrientationTest", []Layout{LayoutSeparate, LayoutEmbedded, LayoutBinary}},
+		{"Cameras", []Layout{LayoutSeparate, LayoutEmbedded}},
+		{"Triangle", []Layout{LayoutSeparate, LayoutEmbedded}},
+		{"TriangleWithoutIndices", []Layout{LayoutSeparate, LayoutEmbedded}},
+	}
+	open := func(t *testing.T, model string, layout Layout) *Document {
+		doc, err := Open("testdata/" + model + "/" + fmt.Sprintf(variants[layout], model))
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		return doc
+	}
+	for _, m := range models {
+		for _, from := range m.layouts {
+			for _, to := range m.layouts {
+				t.Run(fmt.Sprintf("%s/%d_%d", m.name, from, to), func(t *testing.T) {
+					got, fsys := convertMemory(t, open(t, m.name, from), to, nil)
+					want := open(t, m.name, from)
+					for i, buffer := range open(t, m.name, to).Buffers {
+						if !bytes.Equal(got.Buffers[i].Data, buffer.Data) {
+							t.Errorf("Convert() buffers[%d] data differs from the %s variant", i, variants[to])
+						}
+					}
+					for i := range got.Buffers {
+						uri := got.Buffers[i].URI
+						switch {
+						case to == LayoutBinary && (uri != "" || len(got.Buffers) != 1):
+							t.Errorf("Convert() buffers[%d].uri = %s, want a single BIN buffer", i, uri)
+						case to == LayoutEmbedded && !IsDataURI(uri):
+							t.Errorf("Convert() buffers[%d].uri = %s, want a data URI", i, uri)
+						case to == LayoutSeparate && fsys[uri] == nil:
+							t.Errorf("Convert() buffers[%d].uri = %s, want an external file", i, uri)
+						}
+						got.Buffers[i].URI = ""
+						want.Buffers[i].URI = ""
+					}
+					if diff := deep.Equal(got, want); diff != nil {
+						t.Errorf("Convert() = %v", diff)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestConvert_mergeBuffers(t *testing.T) {
+	png := append([]uint8("\x89PNG\r\n\x1a\n"), 1, 2, 3)
+	doc := &Document{
+		Asset: Asset{Version: "2.0"},
+		Scene: -1,
+		Buffers: []Buffer{
+			{ByteLength: 3, Data: []uint8{1, 2, 3}, Name: "a"},
+			{ByteLength: 2, URI: "b.bin", Data: []uint8{4, 5}},
+			{ByteLength: 5, URI: EncodeDataURI(mimetypeApplicationOctet, []uint8{6, 7, 8, 9, 10})},
+		},
+		BufferViews: []BufferView{
+			{Buffer: 0, ByteOffset: 1, ByteLength: 2},
+			{Buffer: 1, ByteLength: 2},
+			{Buffer: 2, ByteOffset: 2, ByteLength: 3},
+		},
+		Images: []Image{{URI: "a.png", Data: png}},
+	}
+	doc.Buffers[2].Data, _ = doc.Buffers[2].marshalData()
+	got, _ := convertMemory(t, doc, LayoutBinary, &ConvertOptions{Images: true})
+	want := []struct {
+		offset uint32
+		data   []uint8
+	}{{1, []uint8{2, 3}}, {4, []uint8{4, 5}}, {10, []uint8{8, 9, 10}}, {16, png}}
+	if len(got.Buffers) != 1 || got.Buffers[0].Name != "a" || got.Buffers[0].ByteLength != 16+uint32(len(png)) {
+		t.Fatalf("Convert() buffers = %v", got.Buffers)
+	}
+	for i, w := range want {
+		view := got.BufferViews[i]
+		data := got.Buffers[0].Data[view.ByteOffset : view.ByteOffset+view.ByteLength]
+		if view.Buffer != 0 || view.ByteOffset != w.offset || !bytes.Equal(data, w.data) {
+			t.Errorf("Convert() bufferViews[%d] = %v %v, want offset %d %v", i, view, data, w.offset, w.data)
+		}
+	}
+	if im := got.Images[0]; im.URI != "" || im.BufferView != 3 || im.MimeType != "image/png" || !bytes.Equal(im.Data, png) {
+		t.Errorf("Convert() images[0] = %v", im)
+	}
+
+	if err := Convert(got, LayoutEmbedded, nil); err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if !IsDataURI(got.Buffers[0].URI) || got.Images[0].URI != "" {
+		t.Errorf("Convert() = %v, %v, want the buffer view image untouched", got.Buffers, got.Images)
+	}
+	sep, fsys := convertMemory(t, got, LayoutSeparate, &ConvertOptions{Images: true, Name: "model"})
+	if sep.Buffers[0].URI != "model.bin" || sep.Images[0].URI != "model_image0.png" || !bytes.Equal(fsys["model_image0.png"].Bytes(), png) {
+		t.Errorf("Convert() = %v, %v", sep.Buffers, sep.Images)
+	}
+	taken := &Document{
+		Buffers: []Buffer{{ByteLength: 1, Data: []uint8{1}}},
+		Images:  []Image{{URI: "buffer.bin", Data: png}, {URI: "buffer_image2.png", Data: png}, {URI: EncodeDataURI("image/png", png), Data: png}},
+	}
+	if err := Convert(taken, LayoutSeparate, nil); err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if taken.Buffers[0].URI != "buffer_1.bin" || taken.Images[2].URI != "buffer_image2_1.png" {
+		t.Errorf("Convert() = %s, %s, want the counter before the extension", taken.Buffers[0].URI, taken.Images[2].URI)
+	}
+	emb, _ := convertMemory(t, sep, LayoutEmbedded, &ConvertOptions{Images: true})
+	if !IsDataURI(emb.Images[0].URI) || !bytes.Equal(emb.Images[0].Data, png) {
+		t.Errorf("Convert() images = %v", emb.Images)
+	}
+	remote := &Document{Images: []Image{{URI: "http://example.com/b.png"}}}
+	if err := Convert(remote, LayoutBinary, &ConvertOptions{Images: true}); err != nil || remote.Images[0].URI != "http://example.com/b.png" {
+		t.Errorf("Convert() = %v, %v, want the image without data untouched", remote.Images, err)
+	}
+	if err := Convert(emb, Layout(10), nil); err == nil {
+		t.Errorf("Convert() expected unknown layout error")
+	}
+	emb.Buffers[0].Data = emb.Buffers[0].Data[:1]
+	if err := Convert(emb, LayoutBinary, nil); err == nil {
+		t.Errorf("Convert() expected byte length error")
+	}
+}
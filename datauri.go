@@ -0,0 +1,97 @@
+package gltf
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// A DataURI is a RFC 2397 data URI, such as "data:image/png;base64,iVBORw0KGgo=".
+type DataURI struct {
+	MediaType string            // Media type without parameters, such as "image/png".
+	Params    map[string]string // Media type parameters, such as charset.
+	Data      []uint8
+}
+
+// IsDataURI returns true if uri uses the data scheme.
+func IsDataURI(uri string) bool {
+	return len(uri) >= 5 && strings.EqualFold(uri[:5], "data:")
+}
+
+// ParseDataURI decodes a base64 or percent-encoded data URI.
+// The media type defaults to text/plain as defined by RFC 2397.
+func ParseDataURI(uri string) (*DataURI, error) {
+	if !IsDataURI(uri) {
+		return nil, errors.New("gltf: Not a data URI")
+	}
+	comma := strings.IndexByte(uri, ',')
+	if comma < 0 {
+		return nil, errors.New("gltf: Data URI without data")
+	}
+	params := strings.Split(uri[5:comma], ";")
+	isBase64 := len(params) > 1 && strings.EqualFold(params[len(params)-1], "base64")
+	if isBase64 {
+		params = params[:len(params)-1]
+	}
+	d := &DataURI{MediaType: "text/plain"}
+	if params[0] != "" {
+		d.MediaType = strings.ToLower(params[0])
+	}
+	for _, p := range params[1:] {
+		i := strings.IndexByte(p, '=')
+		if i < 0 {
+			return nil, errors.New("gltf: Invalid data URI parameter " + p)
+		}
+		if d.Params == nil {
+			d.Params = make(map[string]string)
+		}
+		value, err := url.PathUnescape(p[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		d.Params[strings.ToLower(p[:i])] = value
+	}
+	if params[0] == "" && d.Params == nil {
+		d.Params = map[string]string{"charset": "US-ASCII"}
+	}
+	data, err := url.PathUnescape(uri[comma+1:])
+	if err != nil {
+		return nil, err
+	}
+	if !isBase64 {
+		d.Data = []uint8(data)
+		return d, nil
+	}
+	if len(data)%4 != 0 {
+		d.Data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(data, "="))
+	} else {
+		d.Data, err = base64.StdEncoding.DecodeString(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// dataURILength returns the length of the data of a data URI without decoding it,
+// or -1 if uri is not a data URI. It exceeds the actual length by up to 2 bytes
+// when the base64 padding is percent-encoded.
+func dataURILength(uri string) int {
+	comma := strings.IndexByte(uri, ',')
+	if !IsDataURI(uri) || comma < 0 {
+		return -1
+	}
+	data := uri[comma+1:]
+	n := len(data) - 2*strings.Count(data, "%")
+	if !strings.HasSuffix(strings.ToLower(uri[:comma]), ";base64") {
+		return n
+	}
+	n -= len(data) - len(strings.TrimRight(data, "="))
+	return n * 3 / 4
+}
+
+// EncodeDataURI returns a base64 data URI holding data with the given media type.
+func EncodeDataURI(mediaType string, data []uint8) string {
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
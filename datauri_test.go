@@ -0,0 +1,82 @@
+package gltf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDataURI(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		want    *DataURI
+		wantErr bool
+	}{
+		{"octet", "data:application/octet-stream;base64,TEST", &DataURI{MediaType: "application/octet-stream", Data: []uint8{76, 68, 147}}, false},
+		{"gltfBuffer", "data:application/gltf-buffer;base64,AQID", &DataURI{MediaType: "application/gltf-buffer", Data: []uint8{1, 2, 3}}, false},
+		{"upperCase", "DATA:Image/PNG;BASE64,AQID", &DataURI{MediaType: "image/png", Data: []uint8{1, 2, 3}}, false},
+		{"params", "data:image/png;name=a%20b.png;base64,AQID", &DataURI{MediaType: "image/png", Params: map[string]string{"name": "a b.png"}, Data: []uint8{1, 2, 3}}, false},
+		{"charset", "data:text/plain;charset=utf-8,a%20b", &DataURI{MediaType: "text/plain", Params: map[string]string{"charset": "utf-8"}, Data: []uint8("a b")}, false},
+		{"default", "data:,a%01", &DataURI{MediaType: "text/plain", Params: map[string]string{"charset": "US-ASCII"}, Data: []uint8{'a', 1}}, false},
+		{"percentBinary", "data:application/octet-stream,%01%02%FF", &DataURI{MediaType: "application/octet-stream", Data: []uint8{1, 2, 255}}, false},
+		{"unpadded", "data:application/octet-stream;base64,AQ", &DataURI{MediaType: "application/octet-stream", Data: []uint8{1}}, false},
+		{"percentBase64", "data:application/octet-stream;base64,AQ%3D%3D", &DataURI{MediaType: "application/octet-stream", Data: []uint8{1}}, false},
+		{"empty", "data:application/octet-stream;base64,", &DataURI{MediaType: "application/octet-stream", Data: []uint8{}}, false},
+		{"notData", "a.bin", nil, true},
+		{"noComma", "data:application/octet-stream;base64", nil, true},
+		{"invalidParam", "data:image/png;name;base64,AQID", nil, true},
+		{"invalidBase64", "data:application/octet-stream;base64,_", nil, true},
+		{"invalidEscape", "data:text/plain,%zz", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDataURI(tt.uri)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseDataURI() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDataURI() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDataURILength(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want int
+	}{
+		{"data:application/octet-stream;base64,TEST", 3},
+		{"data:application/octet-stream;base64,YW55ICsgb2xkICYgZGF0YQ==", 16},
+		{"data:application/octet-stream;base64,AQ", 1},
+		{"DATA:Image/PNG;BASE64,AQID", 3},
+		{"data:application/octet-stream;base64,", 0},
+		{"data:application/octet-stream,%01%02%FF", 3},
+		{"data:,a%20b", 3},
+		{"data:application/octet-stream;base64,AQ%3D%3D", 3},
+		{"data:application/octet-stream;base64", -1},
+		{"a.bin", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			if got := dataURILength(tt.uri); got != tt.want {
+				t.Errorf("dataURILength() = %v, want %v", got, tt.want)
+			}
+			if d, err := ParseDataURI(tt.uri); err == nil && (len(d.Data) > tt.want || len(d.Data) < tt.want-2) {
+				t.Errorf("dataURILength() = %v, but the data has %d bytes", tt.want, len(d.Data))
+			}
+		})
+	}
+}
+
+func TestEncodeDataURI(t *testing.T) {
+	uri := EncodeDataURI("image/ktx2", []uint8{1, 2, 3})
+	if uri != "data:image/ktx2;base64,AQID" {
+		t.Errorf("EncodeDataURI() = %v", uri)
+	}
+	got, err := ParseDataURI(uri)
+	if err != nil || got.MediaType != "image/ktx2" || !reflect.DeepEqual(got.Data, []uint8{1, 2, 3}) {
+		t.Errorf("ParseDataURI(EncodeDataURI()) = %v, %v", got, err)
+	}
+}
@@ -3,35 +3,73 @@ package gltf
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"io/ioutil"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
-	"strings"
+	"sync"
+	"sync/atomic"
 	"unsafe"
 )
 
 // ReadQuotas defines maximum allocation sizes to prevent DOS's from malicious files.
+// MaxBufferCount and MaxMemoryAllocation are always enforced,
+// the rest of the quotas are disabled when they are zero.
 type ReadQuotas struct {
 	MaxBufferCount      int
-	MaxMemoryAllocation int
+	MaxMemoryAllocation int // Maximum size of each buffer and image.
+	MaxTotalAllocation  int // Maximum size of all the buffers and images together.
+	MaxJSONSize         int // Maximum size of the JSON content.
+	MaxArrayLength      int // Maximum length of the top level arrays, such as nodes or accessors.
+	MaxExtrasDepth      int // Maximum number of nested objects and arrays in any extras property or extension.
+	MaxImageCount       int
+	MaxImageAllocation  int // Maximum size of all the images together, not counting the ones stored in a buffer view.
 }
 
 // ReadResourceCallback defines a callback that will be called when an external resource should be loaded.
 // The string parameter is the URI of the resource.
 type ReadResourceCallback = func(string) (io.ReadCloser, error)
 
+// sizer is implemented by the resources that know their size before being read,
+// such as the responses of an HTTPLoader, whose size is checked before reading them.
+// The method is unexported so the readers of the callbacks cannot implement it by accident.
+type sizer interface {
+	size() int64
+}
+
+// ReadResourceContextCallback is a ReadResourceCallback that receives the context of the decoding,
+// so long reads can be aborted as soon as it is done.
+type ReadResourceContextCallback = func(context.Context, string) (io.ReadCloser, error)
+
 // Open will open a glTF or GLB file specified by name and return the Document.
 func Open(name string) (*Document, error) {
-	f, err := os.Open(name)
+	return OpenFS(os.DirFS(filepath.Dir(name)), filepath.Base(name))
+}
+
+// OpenFS will open a glTF or GLB file specified by name from the file system fsys and return the Document.
+// External resources are read from fsys relative to the directory of name.
+// Their URIs are percent-decoded and cannot point outside of that directory.
+// The images with an http or https URI are left without data.
+func OpenFS(fsys fs.FS, name string) (*Document, error) {
+	f, err := fsys.Open(name)
 	if err != nil {
 		return nil, err
 	}
+	dir := path.Dir(name)
 	cb := func(uri string) (io.ReadCloser, error) {
-		return os.Open(filepath.Join(filepath.Dir(name), uri))
+		p, err := resolveURI(uri)
+		if err != nil {
+			return nil, err
+		}
+		return fsys.Open(path.Join(dir, p))
 	}
 	doc := new(Document)
 	err = NewDecoder(f, cb).Decode(doc)
@@ -39,15 +77,65 @@ func Open(name string) (*Document, error) {
 	return doc, err
 }
 
+// maxScratch is the capacity over which a scratch buffer is not pooled,
+// so a single big document does not stay in memory.
+const maxScratch = 1 << 20
+
+var scratchPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+func getScratch() *bytes.Buffer {
+	b := scratchPool.Get().(*bytes.Buffer)
+	b.Reset()
+	return b
+}
+
+func putScratch(b *bytes.Buffer) {
+	if b.Cap() <= maxScratch {
+		scratchPool.Put(b)
+	}
+}
+
 // A Decoder reads and decodes glTF and GLB values from an input stream.
 type Decoder struct {
-	r      *bufio.Reader
-	cb     ReadResourceCallback
-	quotas ReadQuotas
+	r           *bufio.Reader
+	cb          ReadResourceContextCallback
+	quotas      ReadQuotas
+	parallelism int
+	lazy        bool
+	strict      bool
+	preserve    bool
+	progress    ProgressFunc
+	mapped      *mappedReader
+	bin         []uint8 // BIN chunk data given back with Release, reused by the next GLB.
+	sandboxed   bool    // The callback confines the relative URIs, which can leave the document directory.
+}
+
+// allocation counts the bytes allocated for a decoded document,
+// including the ones of the resources loaded lazily once the decoding is done.
+type allocation struct {
+	total  atomic.Int64 // Bytes of buffers and images, checked against MaxTotalAllocation.
+	images atomic.Int64 // Bytes of images, checked against MaxImageAllocation.
 }
 
 // NewDecoder returns a new decoder that reads from r.
 func NewDecoder(r io.Reader, cb ReadResourceCallback) *Decoder {
+	return NewDecoderContext(r, contextCallback(cb))
+}
+
+func contextCallback(cb ReadResourceCallback) ReadResourceContextCallback {
+	if cb == nil {
+		return nil
+	}
+	return func(_ context.Context, uri string) (io.ReadCloser, error) {
+		return cb(uri)
+	}
+}
+
+// NewDecoderContext returns a new decoder that reads from r
+// and loads the external resources using a context-aware callback.
+func NewDecoderContext(r io.Reader, cb ReadResourceContextCallback) *Decoder {
 	return &Decoder{
 		r:  bufio.NewReader(r),
 		cb: cb,
@@ -57,54 +145,297 @@ func NewDecoder(r io.Reader, cb ReadResourceCallback) *Decoder {
 		}}
 }
 
+// Reset makes the decoder read a new document from r using cb,
+// keeping its read buffer, its quotas and the rest of its options.
+// It allows reusing a single decoder to decode many documents.
+// The lazy loaders of the documents already decoded keep using the previous callback and quotas.
+func (d *Decoder) Reset(r io.Reader, cb ReadResourceCallback) {
+	d.ResetContext(r, contextCallback(cb))
+}
+
+// ResetContext is like Reset but uses a context-aware callback.
+func (d *Decoder) ResetContext(r io.Reader, cb ReadResourceContextCallback) {
+	if d.r == nil {
+		d.r = bufio.NewReader(r)
+	} else {
+		d.r.Reset(r)
+	}
+	d.cb = cb
+	d.mapped = nil
+}
+
+// maxBIN is the capacity over which the data of a BIN chunk is not kept by Release.
+const maxBIN = 16 << 20
+
+// Release gives the data of the BIN chunk of doc, which was decoded from a GLB by d, back to d,
+// so the next GLB decoded after a Reset reuses it instead of allocating it.
+// Neither doc nor the data of its first buffer must be used afterwards.
+func (d *Decoder) Release(doc *Document) {
+	if len(doc.Buffers) == 0 || !doc.Buffers[0].releasable {
+		return
+	}
+	if data := doc.Buffers[0].Data; cap(data) > cap(d.bin) && cap(data) <= maxBIN {
+		d.bin = data[:0]
+	}
+	doc.Buffers[0].Data, doc.Buffers[0].releasable = nil, false
+}
+
 // SetQuotas sets the read memory limits. The return value is the same decoder.
 func (d *Decoder) SetQuotas(quotas ReadQuotas) *Decoder {
 	d.quotas = quotas
 	return d
 }
 
+// SetParallelism sets the maximum number of external resources that are loaded concurrently.
+// Values lower than 2 load the resources sequentially, which is the default.
+// The callback must be safe for concurrent use when n is greater than 1.
+// The return value is the same decoder.
+func (d *Decoder) SetParallelism(n int) *Decoder {
+	d.parallelism = n
+	return d
+}
+
+// SetLazy enables or disables the lazy loading of external resources.
+// A lazy decoder only validates the buffers and images while decoding,
+// their data is read on demand by Buffer.Load and Image.Load using the same callback and quotas.
+// The BIN chunk of a GLB file is always read while decoding.
+// The return value is the same decoder.
+func (d *Decoder) SetLazy(lazy bool) *Decoder {
+	d.lazy = lazy
+	return d
+}
+
+// SetStrict enables or disables the strict decoding of the JSON content.
+// A strict decoder fails with a *StrictError when a property is unknown, duplicated or of the wrong type.
+// Property names are case sensitive and the members of extensions and extras are not checked.
+// The return value is the same decoder.
+func (d *Decoder) SetStrict(strict bool) *Decoder {
+	d.strict = strict
+	return d
+}
+
+// SetPreserve enables or disables keeping the original JSON content in the decoded document,
+// so the Encoder writes back the unknown properties, the original text of the numbers
+// and the original order of the keys of everything that has not been modified.
+// Modified values are written as usual and new keys are appended to their objects.
+// The unchanged elements of an array keep their original text even if they moved,
+// but once elements are added or removed the modified ones lose their unknown properties.
+// The return value is the same decoder.
+func (d *Decoder) SetPreserve(preserve bool) *Decoder {
+	d.preserve = preserve
+	return d
+}
+
+// SetProgress sets a callback that reports the progress of the JSON content, the buffers and the images.
+// The return value is the same decoder.
+func (d *Decoder) SetProgress(fn ProgressFunc) *Decoder {
+	d.progress = fn
+	return d
+}
+
 // Decode reads the next JSON-encoded value from its
 // input and stores it in the value pointed to by doc.
+// The data of every buffer and image is loaded so the resulting document is self-contained.
 func (d *Decoder) Decode(doc *Document) error {
-	isBinary, err := d.decodeDocument(doc)
+	return d.DecodeContext(context.Background(), doc)
+}
+
+// DecodeContext is like Decode but aborts the decoding as soon as ctx is done,
+// in which case the context error is returned.
+func (d *Decoder) DecodeContext(ctx context.Context, doc *Document) error {
+	glb, err := d.decodeDocument(ctx, doc)
 	if err != nil {
 		return err
 	}
 	if len(doc.Buffers) > d.quotas.MaxBufferCount {
-		return errors.New("gltf: Quota exceeded, number of buffer > MaxBufferCount")
+		return &QuotaError{Quota: "MaxBufferCount", Path: "buffers", Limit: d.quotas.MaxBufferCount, Value: len(doc.Buffers)}
+	}
+	if err := checkDocumentQuotas(d.quotas, doc); err != nil {
+		return err
 	}
+	var allocated int64
+	for i, b := range doc.Buffers {
+		if i == 0 && glb != nil && d.mapped != nil && b.URI == "" {
+			// The BIN chunk is a slice of the mapped file.
+			continue
+		}
+		allocated += int64(b.ByteLength)
+	}
+	alloc := new(allocation)
+	alloc.total.Store(allocated)
 
 	var externalBufferIndex = 0
-	if isBinary && len(doc.Buffers) > 0 {
-		externalBufferIndex = 1
-		if err := d.decodeBinaryBuffer(&doc.Buffers[0]); err != nil {
+	if glb != nil {
+		hasBIN, err := d.decodeChunks(ctx, alloc, doc, glb)
+		if err != nil {
 			return err
 		}
-	}
-	for i := externalBufferIndex; i < len(doc.Buffers); i++ {
-		if err := d.decodeBuffer(&doc.Buffers[i]); err != nil {
-			return err
+		if hasBIN {
+			externalBufferIndex = 1
 		}
 	}
-	return nil
+	err = d.loadResources(ctx, externalBufferIndex, len(doc.Buffers), func(ctx context.Context, i int) error {
+		return d.decodeBuffer(ctx, i, &doc.Buffers[i])
+	})
+	if err != nil {
+		return err
+	}
+	// Images are loaded once all the buffers are available, as they can point to a buffer view.
+	return d.loadResources(ctx, 0, len(doc.Images), func(ctx context.Context, i int) error {
+		return d.decodeImage(ctx, alloc, doc, i)
+	})
 }
 
-func (d *Decoder) decodeDocument(doc *Document) (isBinary bool, err error) {
-	glbHeader, err := d.readGLBHeader()
+func (d *Decoder) decodeDocument(ctx context.Context, doc *Document) (*glbHeader, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	glb, err := d.readGLBHeader()
 	if err != nil {
-		return
+		return nil, err
+	}
+	total := int64(-1)
+	if glb != nil {
+		if max := d.quotas.MaxJSONSize; max > 0 && int(glb.JSONHeader.Length) > max {
+			return nil, &QuotaError{Quota: "MaxJSONSize", Limit: max, Value: int(glb.JSONHeader.Length)}
+		}
+		total = int64(glb.JSONHeader.Length)
+	}
+	pr := newProgress(d.progress, PhaseJSON, 0, "", total)
+
+	// The whole value is read at once, which avoids the json.Decoder buffering,
+	// so it is available to locate the syntax errors and to check it before decoding it.
+	var raw *bytes.Buffer
+	if d.preserve {
+		// The document keeps the content, so it cannot be pooled.
+		raw = new(bytes.Buffer)
+	} else {
+		raw = getScratch()
+		defer putScratch(raw)
 	}
-	var jd *json.Decoder
-	if glbHeader != nil {
-		jd = json.NewDecoder(&io.LimitedReader{R: d.r, N: int64(glbHeader.JSONHeader.Length)})
-		isBinary = true
+	var chunk *io.LimitedReader
+	if glb != nil {
+		chunk = &io.LimitedReader{R: pr.reader(&contextReader{ctx: ctx, r: d.r}), N: int64(glb.JSONHeader.Length)}
+		_, err = raw.ReadFrom(chunk)
 	} else {
-		jd = json.NewDecoder(d.r)
-		isBinary = false
+		err = d.readJSONValue(ctx, pr, raw)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = checkJSONQuotas(d.quotas, raw.Bytes())
+	if err == nil && d.strict {
+		err = checkStrict(raw.Bytes())
 	}
+	if err == nil {
+		err = json.Unmarshal(raw.Bytes(), doc)
+	}
+	if err != nil {
+		return nil, newSyntaxError(err, raw.Bytes())
+	}
+	if d.preserve {
+		doc.raw = raw.Bytes()
+	}
+	if chunk != nil {
+		// Skip the padding of the JSON chunk.
+		if _, err = io.Copy(ioutil.Discard, chunk); err != nil {
+			return nil, err
+		}
+		if chunk.N > 0 {
+			return nil, &GLBError{Reason: "JSON chunk", Err: io.ErrUnexpectedEOF}
+		}
+	}
+	pr.finish()
+	return glb, nil
+}
+
+// readJSONValue appends the next JSON value of the input to buf, leaving the data that follows it unread.
+// The end of the value is found by matching its brackets, so the invalid content is left to json.Unmarshal to report.
+func (d *Decoder) readJSONValue(ctx context.Context, pr *progress, buf *bytes.Buffer) error {
+	var s jsonValueScanner
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := d.r.Peek(1); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		data, _ := d.r.Peek(d.r.Buffered())
+		n, done := s.scan(data)
+		buf.Write(data[:n])
+		d.r.Discard(n)
+		pr.add(n)
+		if max := d.quotas.MaxJSONSize; max > 0 && buf.Len() > max {
+			return &QuotaError{Quota: "MaxJSONSize", Limit: max, Value: buf.Len()}
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// jsonValueScanner finds the end of a JSON value fed in consecutive pieces.
+type jsonValueScanner struct {
+	depth    int
+	inString bool
+	escaped  bool
+	scalar   bool // A number or a literal is being read at the top level.
+}
 
-	err = jd.Decode(doc)
-	return
+// scan returns the length of the prefix of data that belongs to the value and whether the value ends there.
+func (s *jsonValueScanner) scan(data []byte) (int, bool) {
+	for i, c := range data {
+		if s.inString {
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case c == '\\':
+				s.escaped = true
+			case c == '"':
+				s.inString = false
+				if s.depth == 0 {
+					return i + 1, true
+				}
+			}
+			continue
+		}
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			if s.scalar {
+				return i, true
+			}
+		case '"', '{', '[':
+			if s.scalar {
+				return i, true
+			}
+			if c == '"' {
+				s.inString = true
+			} else {
+				s.depth++
+			}
+		case '}', ']':
+			if s.scalar {
+				return i, true
+			}
+			if s.depth--; s.depth <= 0 {
+				return i + 1, true
+			}
+		case ',', ':':
+			if s.depth == 0 {
+				if s.scalar {
+					return i, true
+				}
+				return i + 1, true
+			}
+		default:
+			if s.depth == 0 {
+				s.scalar = true
+			}
+		}
+	}
+	return len(data), false
 }
 
 func (d *Decoder) readGLBHeader() (*glbHeader, error) {
@@ -119,11 +450,12 @@ func (d *Decoder) readGLBHeader() (*glbHeader, error) {
 		return nil, nil
 	}
 	d.r.Read(chunk)
-	if int(header.Length) > d.quotas.MaxMemoryAllocation {
-		return nil, errors.New("gltf: Quota exceeded, bytes of glb buffer > MaxMemoryAllocation")
+	// A mapped file is not allocated, so its size is not limited.
+	if d.mapped == nil && int(header.Length) > d.quotas.MaxMemoryAllocation {
+		return nil, &QuotaError{Quota: "MaxMemoryAllocation", Limit: d.quotas.MaxMemoryAllocation, Value: int(header.Length)}
 	}
 	if header.JSONHeader.Type != glbChunkJSON || (header.JSONHeader.Length+uint32(unsafe.Sizeof(header))) > header.Length {
-		return nil, errors.New("gltf: Invalid GLB JSON header")
+		return nil, &GLBError{Reason: "JSON chunk header"}
 	}
 	return &header, nil
 }
@@ -136,58 +468,362 @@ func (d *Decoder) chunkHeader() (*chunkHeader, error) {
 	return &header, nil
 }
 
-func (d *Decoder) decodeBuffer(buffer *Buffer) error {
-	if err := d.validateBuffer(buffer); err != nil {
+func (d *Decoder) decodeBuffer(ctx context.Context, index int, buffer *Buffer) error {
+	path := indexPath("buffers", index)
+	if err := d.validateBuffer(path, buffer); err != nil {
 		return err
 	}
 	if buffer.URI == "" {
-		return errors.New("gltf: buffer without URI")
+		return &ValidationError{Path: path + ".uri", Tag: "required", Value: buffer.URI}
 	}
-	var err error
-	var r io.ReadCloser
-	if buffer.IsEmbeddedResource() {
-		buffer.Data, err = buffer.marshalData()
-	} else if err = validateBufferURI(buffer.URI); err == nil {
-		r, err = d.cb(buffer.URI)
-		if err == nil {
-			buffer.Data = make([]uint8, buffer.ByteLength)
-			_, err = r.Read(buffer.Data)
-			r.Close()
+	if !buffer.IsEmbeddedResource() {
+		if err := d.validateURI(path, buffer.URI); err != nil {
+			return err
+		}
+	}
+	uri, byteLength := buffer.URI, buffer.ByteLength
+	if d.lazy {
+		ld := d.loaderDecoder()
+		buffer.loader = func(ctx context.Context) ([]uint8, error) {
+			return ld.readBuffer(ctx, index, uri, byteLength)
 		}
+		return nil
 	}
+	var err error
+	buffer.Data, err = d.readBuffer(ctx, index, uri, byteLength)
 	return err
 }
 
-func (d *Decoder) decodeBinaryBuffer(buffer *Buffer) error {
-	if err := d.validateBuffer(buffer); err != nil {
+// loaderDecoder returns a copy of the resource settings of d for a lazy loader,
+// which runs once the decoding is done and must not see a later Reset or SetQuotas.
+func (d *Decoder) loaderDecoder() *Decoder {
+	return &Decoder{cb: d.cb, quotas: d.quotas, progress: d.progress, sandboxed: d.sandboxed}
+}
+
+// validateURI is like validateBufferURI, but the relative URIs of a sandboxed decoder
+// can start with dot-dot segments, as the Sandbox keeps them inside its root.
+func (d *Decoder) validateURI(path, uri string) error {
+	if d.sandboxed && uri != "" {
+		if u, err := url.Parse(uri); err == nil && u.Scheme == "" {
+			if _, err = uriPath(uri); err != nil {
+				return &URIError{Path: path, URI: uri, Err: err}
+			}
+			return nil
+		}
+	}
+	return validateBufferURI(path, uri)
+}
+
+func (d *Decoder) readBuffer(ctx context.Context, index int, uri string, byteLength uint32) ([]uint8, error) {
+	path := indexPath("buffers", index)
+	pr := newProgress(d.progress, PhaseBuffer, index, uri, int64(byteLength))
+	if IsDataURI(uri) {
+		// The length is checked before decoding, so an oversized payload is never allocated.
+		if n := dataURILength(uri); n > d.quotas.MaxMemoryAllocation {
+			return nil, &QuotaError{Quota: "MaxMemoryAllocation", Path: path, Limit: d.quotas.MaxMemoryAllocation, Value: n}
+		} else if n >= 0 && n < int(byteLength) {
+			return nil, &ByteLengthError{Path: path, URI: uri, ByteLength: byteLength, Length: int64(n)}
+		}
+		data, err := (&Buffer{URI: uri}).marshalData()
+		if err != nil {
+			return nil, &URIError{Path: path, URI: uri, Err: err}
+		}
+		if len(data) < int(byteLength) {
+			return nil, &ByteLengthError{Path: path, URI: uri, ByteLength: byteLength, Length: int64(len(data))}
+		}
+		pr.add(len(data))
+		pr.finish()
+		return data, nil
+	}
+	r, err := d.cb(ctx, uri)
+	if err != nil {
+		return nil, &ResourceError{Path: path, URI: uri, Err: err}
+	}
+	if s, ok := r.(sizer); ok && s.size() >= 0 && s.size() != int64(byteLength) {
+		r.Close()
+		return nil, &ByteLengthError{Path: path, URI: uri, ByteLength: byteLength, Length: s.size()}
+	}
+	data := make([]uint8, byteLength)
+	err = readResource(pr.reader(&contextReader{ctx: ctx, r: r}), data, uri)
+	r.Close()
+	if e, ok := err.(*ByteLengthError); ok {
+		e.Path = path
+		return nil, e
+	} else if err != nil {
+		return nil, &ResourceError{Path: path, URI: uri, Err: err}
+	}
+	pr.finish()
+	return data, nil
+}
+
+// decodeChunks reads the chunks that follow the JSON chunk of a GLB file.
+// A BIN chunk right after the JSON chunk holds the data of the first buffer,
+// any other chunk type is unknown and stored in doc.Chunks.
+func (d *Decoder) decodeChunks(ctx context.Context, alloc *allocation, doc *Document, glb *glbHeader) (hasBIN bool, err error) {
+	offset := uint32(unsafe.Sizeof(*glb)) + glb.JSONHeader.Length
+	for first := true; offset < glb.Length; first = false {
+		if glb.Length-offset < uint32(unsafe.Sizeof(chunkHeader{})) {
+			return false, &GLBError{Reason: "chunk header exceeds the file length"}
+		}
+		header, err := d.chunkHeader()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return false, &GLBError{Reason: "chunk header", Err: err}
+		}
+		offset += uint32(unsafe.Sizeof(*header))
+		if header.Length > glb.Length-offset {
+			return false, &GLBError{Reason: fmt.Sprintf("chunk length %d exceeds the file length", header.Length)}
+		}
+		offset += header.Length
+		switch {
+		case header.Type == glbChunkBIN && first && len(doc.Buffers) > 0:
+			hasBIN = true
+			err = d.decodeBinaryBuffer(ctx, &doc.Buffers[0], header)
+		case header.Type == glbChunkBIN && first:
+			// A BIN chunk without buffers has no meaning.
+			if _, err = d.r.Discard(int(header.Length)); err == io.EOF {
+				err = &GLBError{Reason: "BIN chunk", Err: io.ErrUnexpectedEOF}
+			}
+		case header.Type == glbChunkJSON || header.Type == glbChunkBIN:
+			err = &GLBError{Reason: "duplicated chunk"}
+		default:
+			var data []uint8
+			if data, err = d.readChunk(ctx, alloc, header.Length); err == nil {
+				doc.Chunks = append(doc.Chunks, Chunk{Type: header.Type, Data: data})
+			}
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	if _, err := d.r.Peek(1); err == nil {
+		return false, &GLBError{Reason: "trailing data after the last chunk"}
+	}
+	return hasBIN, nil
+}
+
+// readChunk reads the data of an unknown chunk.
+func (d *Decoder) readChunk(ctx context.Context, alloc *allocation, length uint32) ([]uint8, error) {
+	if d.mapped != nil {
+		start := d.mapped.offset(d.r.Buffered())
+		if len(d.mapped.data)-start < int(length) {
+			return nil, &GLBError{Reason: "chunk", Err: io.ErrUnexpectedEOF}
+		}
+		end := start + int(length)
+		_, err := d.r.Discard(int(length))
+		return d.mapped.data[start:end:end], err
+	}
+	if int(length) > d.quotas.MaxMemoryAllocation {
+		return nil, &QuotaError{Quota: "MaxMemoryAllocation", Limit: d.quotas.MaxMemoryAllocation, Value: int(length)}
+	}
+	if n := alloc.total.Add(int64(length)); d.quotas.MaxTotalAllocation > 0 && n > int64(d.quotas.MaxTotalAllocation) {
+		return nil, &QuotaError{Quota: "MaxTotalAllocation", Limit: d.quotas.MaxTotalAllocation, Value: int(n)}
+	}
+	data := make([]uint8, length)
+	if _, err := io.ReadFull(&contextReader{ctx: ctx, r: d.r}, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, &GLBError{Reason: "chunk", Err: err}
+	}
+	return data, nil
+}
+
+func (d *Decoder) decodeBinaryBuffer(ctx context.Context, buffer *Buffer, header *chunkHeader) error {
+	if d.mapped != nil {
+		// The data is a slice of the mapped file, so it is not limited by MaxMemoryAllocation.
+		if buffer.ByteLength == 0 {
+			return &ValidationError{Path: "buffers[0].byteLength", Tag: "required", Value: buffer.ByteLength}
+		}
+	} else if err := d.validateBuffer("buffers[0]", buffer); err != nil {
+		return err
+	}
+	// The chunk can only be padded with up to 3 trailing bytes.
+	if header.Length < buffer.ByteLength || header.Length-buffer.ByteLength > 3 {
+		return &ByteLengthError{Path: "buffers[0]", ByteLength: buffer.ByteLength, Length: int64(header.Length)}
+	}
+	pr := newProgress(d.progress, PhaseBuffer, 0, "", int64(buffer.ByteLength))
+	if d.mapped != nil {
+		err := d.sliceBinaryBuffer(buffer, header)
+		if err == nil {
+			pr.add(len(buffer.Data))
+		}
 		return err
 	}
-	header, err := d.chunkHeader()
+	if cap(d.bin) >= int(buffer.ByteLength) {
+		buffer.Data, d.bin = d.bin[:buffer.ByteLength], nil
+	} else {
+		buffer.Data = make([]uint8, buffer.ByteLength)
+	}
+	buffer.releasable = true
+	n, err := io.ReadFull(pr.reader(&contextReader{ctx: ctx, r: d.r}), buffer.Data)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return &ByteLengthError{Path: "buffers[0]", ByteLength: buffer.ByteLength, Length: int64(n)}
+	}
 	if err != nil {
 		return err
 	}
-	if header.Type != glbChunkBIN || header.Length < buffer.ByteLength {
-		return errors.New("gltf: Invalid GLB BIN header")
+	_, err = d.r.Discard(int(header.Length - buffer.ByteLength))
+	if err == io.EOF {
+		return &ByteLengthError{Path: "buffers[0]", ByteLength: buffer.ByteLength, Length: int64(header.Length)}
 	}
-	buffer.Data = make([]uint8, buffer.ByteLength)
-	_, err = d.r.Read(buffer.Data)
 	return err
 }
 
-func (d *Decoder) validateBuffer(buffer *Buffer) error {
-	if buffer.ByteLength == 0 {
-		return errors.New("gltf: Invalid buffer.byteLength value = 0")
+// sliceBinaryBuffer points the buffer data to the BIN chunk of a memory-mapped file.
+func (d *Decoder) sliceBinaryBuffer(buffer *Buffer, header *chunkHeader) error {
+	start := d.mapped.offset(d.r.Buffered())
+	if available := len(d.mapped.data) - start; available < int(header.Length) {
+		n := available
+		if n > int(buffer.ByteLength) {
+			n = int(buffer.ByteLength)
+		}
+		return &ByteLengthError{Path: "buffers[0]", ByteLength: buffer.ByteLength, Length: int64(n)}
 	}
+	end := start + int(buffer.ByteLength)
+	buffer.Data = d.mapped.data[start:end:end]
+	_, err := d.r.Discard(int(header.Length))
+	return err
+}
 
-	if int(buffer.ByteLength) > d.quotas.MaxMemoryAllocation {
-		return errors.New("gltf: Quota exceeded, bytes of buffer > MaxMemoryAllocation")
+// readResource fills data with the content of r,
+// failing with a ByteLengthError if r does not contain exactly len(data) bytes.
+func readResource(r io.Reader, data []uint8, uri string) error {
+	n, err := io.ReadFull(r, data)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return &ByteLengthError{URI: uri, ByteLength: uint32(len(data)), Length: int64(n)}
+	}
+	if err != nil {
+		return err
+	}
+	var extra [1]uint8
+	n, err = io.ReadFull(r, extra[:])
+	if n != 0 {
+		return &ByteLengthError{URI: uri, ByteLength: uint32(len(data)), Length: int64(len(data) + n)}
+	}
+	if err != io.EOF {
+		return err
 	}
 	return nil
 }
 
-func validateBufferURI(uri string) error {
-	if uri == "" || strings.Contains(uri, "..") || strings.HasPrefix(uri, "/") || strings.HasPrefix(uri, "\\") {
-		return fmt.Errorf("gltf: Invalid buffer.uri value '%s'", uri)
+func (d *Decoder) decodeImage(ctx context.Context, alloc *allocation, doc *Document, index int) error {
+	path := indexPath("images", index)
+	image := &doc.Images[index]
+	if image.URI != "" && !image.IsEmbeddedResource() {
+		if err := d.validateURI(path, image.URI); err != nil {
+			return err
+		}
+	}
+	uri, bufferView := image.URI, image.BufferView
+	if d.lazy {
+		ld := d.loaderDecoder()
+		image.loader = func(ctx context.Context) ([]uint8, error) {
+			return ld.readImage(ctx, alloc, doc, index, uri, bufferView)
+		}
+		return nil
+	}
+	var err error
+	image.Data, err = d.readImage(ctx, alloc, doc, index, uri, bufferView)
+	return err
+}
+
+func (d *Decoder) readImage(ctx context.Context, alloc *allocation, doc *Document, index int, uri string, bufferView uint32) ([]uint8, error) {
+	path := indexPath("images", index)
+	var data []uint8
+	var err error
+	switch {
+	case uri == "":
+		if data, err = imageBufferViewData(ctx, doc, path, bufferView); err == nil {
+			pr := newProgress(d.progress, PhaseImage, index, uri, int64(len(data)))
+			pr.add(len(data))
+		}
+		return data, err
+	case (&Image{URI: uri}).IsEmbeddedResource():
+		if n := dataURILength(uri); n > d.quotas.MaxMemoryAllocation {
+			return nil, &QuotaError{Quota: "MaxMemoryAllocation", Path: path, Limit: d.quotas.MaxMemoryAllocation, Value: n}
+		}
+		if data, err = (&Image{URI: uri}).MarshalData(); err != nil {
+			return nil, &URIError{Path: path, URI: uri, Err: err}
+		}
+		pr := newProgress(d.progress, PhaseImage, index, uri, int64(len(data)))
+		pr.add(len(data))
+	case d.cb == nil:
+		// Without a callback the external images are left without data, as the encoder expects.
+		return nil, nil
+	default:
+		r, err := d.cb(ctx, uri)
+		if errors.Is(err, errRemoteURI) {
+			// The remote images cannot be read from a file system, so they are left without data.
+			return nil, nil
+		}
+		if err != nil {
+			return nil, &ResourceError{Path: path, URI: uri, Err: err}
+		}
+		size := int64(-1)
+		if s, ok := r.(sizer); ok && s.size() >= 0 {
+			size = s.size()
+		}
+		if size > int64(d.quotas.MaxMemoryAllocation) {
+			r.Close()
+			return nil, &QuotaError{Quota: "MaxMemoryAllocation", Path: path, Limit: d.quotas.MaxMemoryAllocation, Value: int(size)}
+		}
+		pr := newProgress(d.progress, PhaseImage, index, uri, size)
+		data, err = ioutil.ReadAll(io.LimitReader(pr.reader(&contextReader{ctx: ctx, r: r}), int64(d.quotas.MaxMemoryAllocation)+1))
+		r.Close()
+		if err != nil {
+			return nil, &ResourceError{Path: path, URI: uri, Err: err}
+		}
+		pr.finish()
+	}
+	if len(data) > d.quotas.MaxMemoryAllocation {
+		return nil, &QuotaError{Quota: "MaxMemoryAllocation", Path: path, Limit: d.quotas.MaxMemoryAllocation, Value: len(data)}
+	}
+	if n := alloc.images.Add(int64(len(data))); d.quotas.MaxImageAllocation > 0 && n > int64(d.quotas.MaxImageAllocation) {
+		return nil, &QuotaError{Quota: "MaxImageAllocation", Path: path, Limit: d.quotas.MaxImageAllocation, Value: int(n)}
+	}
+	if n := alloc.total.Add(int64(len(data))); d.quotas.MaxTotalAllocation > 0 && n > int64(d.quotas.MaxTotalAllocation) {
+		return nil, &QuotaError{Quota: "MaxTotalAllocation", Path: path, Limit: d.quotas.MaxTotalAllocation, Value: int(n)}
+	}
+	return data, nil
+}
+
+// imageBufferViewData returns the slice of the buffer data pointed by the buffer view of the image at path.
+// The buffer is loaded if it was not loaded yet.
+func imageBufferViewData(ctx context.Context, doc *Document, path string, index uint32) ([]uint8, error) {
+	if int(index) >= len(doc.BufferViews) {
+		return nil, &ValidationError{Path: path + ".bufferView", Tag: "lt", Value: index}
+	}
+	return bufferViewData(ctx, doc, int(index))
+}
+
+// bufferViewData returns the slice of the buffer data pointed by the existing buffer view at index.
+// The buffer is loaded if it was not loaded yet.
+func bufferViewData(ctx context.Context, doc *Document, index int) ([]uint8, error) {
+	view := &doc.BufferViews[index]
+	if view.Buffer < 0 || int(view.Buffer) >= len(doc.Buffers) {
+		return nil, &ValidationError{Path: indexPath("bufferViews", index) + ".buffer", Tag: "lt", Value: view.Buffer}
+	}
+	data, err := doc.Buffers[view.Buffer].LoadContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	end := uint64(view.ByteOffset) + uint64(view.ByteLength)
+	if end > uint64(len(data)) {
+		return nil, &ValidationError{Path: indexPath("bufferViews", index) + ".byteLength", Tag: "lte", Value: view.ByteLength}
+	}
+	return data[view.ByteOffset:end], nil
+}
+
+func (d *Decoder) validateBuffer(path string, buffer *Buffer) error {
+	if buffer.ByteLength == 0 {
+		return &ValidationError{Path: path + ".byteLength", Tag: "required", Value: buffer.ByteLength}
+	}
+
+	if int(buffer.ByteLength) > d.quotas.MaxMemoryAllocation {
+		return &QuotaError{Quota: "MaxMemoryAllocation", Path: path, Limit: d.quotas.MaxMemoryAllocation, Value: int(buffer.ByteLength)}
 	}
 	return nil
 }
@@ -2,10 +2,17 @@ package gltf
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
+	"testing/iotest"
+	"time"
 
 	"github.com/go-test/deep"
 )
@@ -15,6 +22,25 @@ func readFile(path string) []uint8 {
 	return r
 }
 
+// deepEqualDocument is like deep.Equal but compares the image data with bytes.Equal,
+// which is much faster for big images.
+func deepEqualDocument(got, want *Document) []string {
+	if got == nil || want == nil || len(got.Images) != len(want.Images) {
+		return deep.Equal(got, want)
+	}
+	var diff []string
+	g, w := *got, *want
+	g.Images, w.Images = make([]Image, len(got.Images)), make([]Image, len(want.Images))
+	for i := range got.Images {
+		g.Images[i], w.Images[i] = got.Images[i], want.Images[i]
+		if !bytes.Equal(g.Images[i].Data, w.Images[i].Data) {
+			diff = append(diff, fmt.Sprintf("Images[%d].Data: data differs", i))
+		}
+		g.Images[i].Data, w.Images[i].Data = nil, nil
+	}
+	return append(diff, deep.Equal(&g, &w)...)
+}
+
 func TestOpen(t *testing.T) {
 	deep.FloatPrecision = 5
 	type args struct {
@@ -42,8 +68,11 @@ func TestOpen(t *testing.T) {
 				{Buffer: 0, ByteLength: 576, ByteOffset: 936, Target: ArrayBuffer},
 				{Buffer: 0, ByteLength: 288, ByteOffset: 1512, Target: ArrayBuffer},
 			},
-			Buffers:   []Buffer{{ByteLength: 1800, URI: "Cube.bin", Data: readFile("testdata/Cube/glTF/Cube.bin")}},
-			Images:    []Image{{URI: "Cube_BaseColor.png"}, {URI: "Cube_MetallicRoughness.png"}},
+			Buffers: []Buffer{{ByteLength: 1800, URI: "Cube.bin", Data: readFile("testdata/Cube/glTF/Cube.bin")}},
+			Images: []Image{
+				{URI: "Cube_BaseColor.png", Data: readFile("testdata/Cube/glTF/Cube_BaseColor.png")},
+				{URI: "Cube_MetallicRoughness.png", Data: readFile("testdata/Cube/glTF/Cube_MetallicRoughness.png")},
+			},
 			Materials: []Material{{Name: "Cube", AlphaMode: Opaque, AlphaCutoff: 0.5, PBRMetallicRoughness: &PBRMetallicRoughness{BaseColorFactor: [4]float64{1, 1, 1, 1}, MetallicFactor: 1, RoughnessFactor: 1, BaseColorTexture: &TextureInfo{Index: 0}, MetallicRoughnessTexture: &TextureInfo{Index: 1}}}},
 			Meshes:    []Mesh{{Name: "Cube", Primitives: []Primitive{{Indices: 0, Material: 0, Mode: Triangles, Attributes: map[string]uint32{"NORMAL": 2, "POSITION": 1, "TANGENT": 3, "TEXCOORD_0": 4}}}}},
 			Nodes:     []Node{{Mesh: 0, Name: "Cube", Camera: -1, Skin: -1, Matrix: [16]float64{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}, Rotation: [4]float64{0, 0, 0, 1}, Scale: [3]float64{1, 1, 1}}},
@@ -139,6 +168,61 @@ func TestOpen(t *testing.T) {
 	}
 }
 
+func TestOpenFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"models/a.gltf":       {Data: []byte(`{"asset":{"version":"2.0"},"buffers":[{"byteLength":3,"uri":"bin/a.bin"}],"images":[{"uri":"a.png"}]}`)},
+		"models/bin/a.bin":    {Data: []byte{1, 2, 3}},
+		"models/a.png":        {Data: []byte{4, 5}},
+		"b.gltf":              {Data: []byte(`{"asset":{"version":"2.0"},"buffers":[{"byteLength":3,"uri":"../models/bin/a.bin"}]}`)},
+		"c.gltf":              {Data: []byte(`{"asset":{"version":"2.0"},"buffers":[{"byteLength":3,"uri":"c.bin"}]}`)},
+		"models/d.gltf":       {Data: []byte(`{"asset":{"version":"2.0"},"buffers":[{"byteLength":1,"uri":"./bin/../my%20model.bin?v=1"}]}`)},
+		"models/my model.bin": {Data: []byte{6}},
+		"models/e.gltf":       {Data: []byte(`{"asset":{"version":"2.0"},"images":[{"uri":"https://example.com/a.png"},{"uri":"a.png"}]}`)},
+		"models/f.gltf":       {Data: []byte(`{"asset":{"version":"2.0"},"images":[{"uri":"file:///a.png"}]}`)},
+	}
+	tests := []struct {
+		name    string
+		want    *Document
+		wantErr bool
+	}{
+		{"models/a.gltf", &Document{
+			Asset:   Asset{Version: "2.0"},
+			Buffers: []Buffer{{ByteLength: 3, URI: "bin/a.bin", Data: []uint8{1, 2, 3}}},
+			Images:  []Image{{URI: "a.png", Data: []uint8{4, 5}}},
+			Scene:   -1,
+		}, false},
+		{"models/d.gltf", &Document{
+			Asset:   Asset{Version: "2.0"},
+			Buffers: []Buffer{{ByteLength: 1, URI: "./bin/../my%20model.bin?v=1", Data: []uint8{6}}},
+			Scene:   -1,
+		}, false},
+		{"models/e.gltf", &Document{
+			Asset:  Asset{Version: "2.0"},
+			Images: []Image{{URI: "https://example.com/a.png"}, {URI: "a.png", Data: []uint8{4, 5}}},
+			Scene:  -1,
+		}, false},
+		{"models/f.gltf", nil, true},
+		{"b.gltf", nil, true},
+		{"c.gltf", nil, true},
+		{"notFound.gltf", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := OpenFS(fsys, tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("OpenFS() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Errorf("OpenFS() = %v", diff)
+			}
+		})
+	}
+}
+
 func readCallback(name string) (io.ReadCloser, error) {
 	return ioutil.NopCloser(bytes.NewBufferString("a")), nil
 }
@@ -158,20 +242,200 @@ func TestDecoder_decodeBuffer(t *testing.T) {
 		{"invalidURI", &Decoder{quotas: ReadQuotas{MaxMemoryAllocation: 2}}, args{&Buffer{ByteLength: 1, URI: "../a.bin"}}, true},
 		{"maxQuota", &Decoder{quotas: ReadQuotas{MaxMemoryAllocation: 2}}, args{&Buffer{ByteLength: 3, URI: "a.bin"}}, true},
 		{"cbErr", NewDecoder(nil, func(name string) (io.ReadCloser, error) { return nil, errors.New("") }), args{&Buffer{ByteLength: 3, URI: "a.bin"}}, true},
-		{"base", NewDecoder(nil, readCallback), args{&Buffer{ByteLength: 3, URI: "a.bin"}}, false},
+		{"truncated", NewDecoder(nil, readCallback), args{&Buffer{ByteLength: 3, URI: "a.bin"}}, true},
+		{"base", NewDecoder(nil, readCallback), args{&Buffer{ByteLength: 1, URI: "a.bin"}}, false},
+		{"sizeMethod", NewDecoder(nil, func(name string) (io.ReadCloser, error) {
+			// The Size of a bytes.Reader is not the length left to read, so it must not be checked.
+			r := bytes.NewReader([]byte("abc"))
+			r.ReadByte()
+			return struct {
+				*bytes.Reader
+				io.Closer
+			}{r, ioutil.NopCloser(nil)}, nil
+		}), args{&Buffer{ByteLength: 2, URI: "a.bin"}}, false},
+		{"gltfBuffer", NewDecoder(nil, nil), args{&Buffer{ByteLength: 3, URI: "data:application/gltf-buffer;base64,AQID"}}, false},
+		{"embeddedTruncated", NewDecoder(nil, nil), args{&Buffer{ByteLength: 4, URI: "data:application/gltf-buffer;base64,AQID"}}, true},
+		{"embeddedMaxQuota", &Decoder{quotas: ReadQuotas{MaxMemoryAllocation: 2}}, args{&Buffer{ByteLength: 1, URI: "data:application/gltf-buffer;base64,AQID"}}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := tt.d.decodeBuffer(tt.args.buffer); (err != nil) != tt.wantErr {
+			if err := tt.d.decodeBuffer(context.Background(), 0, tt.args.buffer); (err != nil) != tt.wantErr {
 				t.Errorf("Decoder.decodeBuffer() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
+func TestDecoder_decodeImage(t *testing.T) {
+	doc := &Document{
+		Buffers:     []Buffer{{ByteLength: 4, Data: []uint8{1, 2, 3, 4}}},
+		BufferViews: []BufferView{{Buffer: 0, ByteOffset: 1, ByteLength: 2}, {Buffer: 1, ByteLength: 2}, {Buffer: 0, ByteOffset: 3, ByteLength: 2}},
+	}
+	type args struct {
+		image *Image
+	}
+	tests := []struct {
+		name    string
+		d       *Decoder
+		args    args
+		want    []uint8
+		wantErr bool
+	}{
+		{"bufferView", new(Decoder), args{&Image{BufferView: 0, MimeType: "image/png"}}, []uint8{2, 3}, false},
+		{"invalidBufferView", new(Decoder), args{&Image{BufferView: 5, MimeType: "image/png"}}, nil, true},
+		{"invalidBuffer", new(Decoder), args{&Image{BufferView: 1, MimeType: "image/png"}}, nil, true},
+		{"outOfBounds", new(Decoder), args{&Image{BufferView: 2, MimeType: "image/png"}}, nil, true},
+		{"embedded", &Decoder{quotas: ReadQuotas{MaxMemoryAllocation: 3}}, args{&Image{URI: "data:image/png;base64,TEST"}}, []uint8{76, 68, 147}, false},
+		{"embeddedMaxQuota", &Decoder{quotas: ReadQuotas{MaxMemoryAllocation: 2}}, args{&Image{URI: "data:image/png;base64,TEST"}}, []uint8{76, 68, 147}, true},
+		{"invalidURI", new(Decoder), args{&Image{URI: "../a.png"}}, nil, true},
+		{"cbErr", NewDecoder(nil, func(name string) (io.ReadCloser, error) { return nil, errors.New("") }), args{&Image{URI: "a.png"}}, nil, true},
+		{"maxQuota", NewDecoder(nil, readCallback).SetQuotas(ReadQuotas{MaxMemoryAllocation: 0}), args{&Image{URI: "a.png"}}, []uint8{}, true},
+		{"base", NewDecoder(nil, readCallback), args{&Image{URI: "a.png"}}, []uint8("a"), false},
+		{"nilCallback", NewDecoder(nil, nil), args{&Image{URI: "a.png"}}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc.Images = []Image{*tt.args.image}
+			if err := tt.d.decodeImage(context.Background(), new(allocation), doc, 0); (err != nil) != tt.wantErr {
+				t.Errorf("Decoder.decodeImage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got := doc.Images[0].Data; !tt.wantErr && !bytes.Equal(got, tt.want) {
+				t.Errorf("Decoder.decodeImage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecoder_Reset(t *testing.T) {
+	d := NewDecoder(bytes.NewBufferString(`{"asset":{"version":"2.0"},"nodes":[{"name":"a"}]}`), nil).SetQuotas(ReadQuotas{MaxBufferCount: 1, MaxMemoryAllocation: 1})
+	doc := new(Document)
+	if err := d.Decode(doc); err != nil || doc.Nodes[0].Name != "a" {
+		t.Fatalf("Decoder.Decode() = %v, %v", doc.Nodes, err)
+	}
+	d.Reset(bytes.NewBufferString(`{"asset":{"version":"2.0"},"buffers":[{"byteLength":1,"uri":"a.bin"}]}`), readCallback)
+	doc = new(Document)
+	if err := d.Decode(doc); err != nil || !bytes.Equal(doc.Buffers[0].Data, []uint8("a")) {
+		t.Fatalf("Decoder.Decode() = %v, %v", doc.Buffers, err)
+	}
+	d.Reset(bytes.NewBufferString(`{"asset":{"version":"2.0"},"buffers":[{"byteLength":2,"uri":"a.bin"}]}`), readCallback)
+	var e *QuotaError
+	if err := d.Decode(new(Document)); !errors.As(err, &e) {
+		t.Errorf("Decoder.Decode() error = %v, want the quotas to be kept", err)
+	}
+	var zero Decoder
+	zero.Reset(bytes.NewBufferString(`{"asset":{"version":"2.0"}}`), nil)
+	if err := zero.Decode(new(Document)); err != nil {
+		t.Errorf("Decoder.Decode() error = %v", err)
+	}
+}
+
+func TestDecoder_SetLazy(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.bin": {Data: []byte{1, 2, 3, 4}},
+		"a.png": {Data: []byte{5, 6}},
+	}
+	var calls int
+	cb := func(uri string) (io.ReadCloser, error) {
+		calls++
+		return fsys.Open(uri)
+	}
+	doc := new(Document)
+	d := NewDecoder(bytes.NewBufferString(`{"asset":{"version":"2.0"},"buffers":[{"byteLength":4,"uri":"a.bin"},{"byteLength":1,"uri":"b.bin"}],
+		"bufferViews":[{"buffer":0,"byteOffset":1,"byteLength":2}],"images":[{"uri":"a.png"},{"bufferView":0,"mimeType":"image/png"}]}`), cb).SetLazy(true)
+	if err := d.Decode(doc); err != nil {
+		t.Fatalf("Decoder.Decode() error = %v", err)
+	}
+	if calls != 0 || doc.Buffers[0].Data != nil || doc.Images[0].Data != nil {
+		t.Fatalf("Decoder.Decode() read %d resources, want 0", calls)
+	}
+	if got, err := doc.Images[1].Load(); err != nil || !bytes.Equal(got, []uint8{2, 3}) {
+		t.Errorf("Image.Load() = %v, %v, want [2 3]", got, err)
+	}
+	if got, err := doc.Buffers[0].Load(); err != nil || !bytes.Equal(got, []uint8{1, 2, 3, 4}) {
+		t.Errorf("Buffer.Load() = %v, %v, want [1 2 3 4]", got, err)
+	}
+	if got, err := doc.Images[0].Load(); err != nil || !bytes.Equal(got, []uint8{5, 6}) {
+		t.Errorf("Image.Load() = %v, %v, want [5 6]", got, err)
+	}
+	if calls != 2 {
+		t.Errorf("Load() read %d resources, want 2", calls)
+	}
+	var resErr *ResourceError
+	if _, err := doc.Buffers[1].Load(); !errors.As(err, &resErr) || resErr.Path != "buffers[1]" {
+		t.Errorf("Buffer.Load() error = %v, want *ResourceError at buffers[1]", err)
+	}
+
+	d = NewDecoder(bytes.NewBufferString(`{"asset":{"version":"2.0"},"buffers":[{"byteLength":4,"uri":"a.bin"}]}`), cb).
+		SetLazy(true).SetQuotas(ReadQuotas{MaxBufferCount: 1, MaxMemoryAllocation: 3})
+	var quotaErr *QuotaError
+	if err := d.Decode(new(Document)); !errors.As(err, &quotaErr) {
+		t.Errorf("Decoder.Decode() error = %v, want *QuotaError", err)
+	}
+}
+
+func TestDecoder_SetLazy_reset(t *testing.T) {
+	cb := func(uri string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewBufferString("abcd")), nil
+	}
+	d := NewDecoder(bytes.NewBufferString(`{"asset":{"version":"2.0"},"buffers":[{"byteLength":4,"uri":"a.bin"}],"images":[{"uri":"a.png"}]}`), cb).
+		SetLazy(true).SetQuotas(ReadQuotas{MaxBufferCount: 1, MaxMemoryAllocation: 4})
+	doc := new(Document)
+	if err := d.Decode(doc); err != nil {
+		t.Fatalf("Decoder.Decode() error = %v", err)
+	}
+	// The loaders keep the callback and the quotas of the decoding.
+	d.Reset(bytes.NewBufferString(`{"asset":{"version":"2.0"}}`), nil)
+	d.SetQuotas(ReadQuotas{MaxBufferCount: 1, MaxMemoryAllocation: 1})
+	if got, err := doc.Buffers[0].Load(); err != nil || string(got) != "abcd" {
+		t.Errorf("Buffer.Load() = %s, %v, want abcd", got, err)
+	}
+	if got, err := doc.Images[0].Load(); err != nil || string(got) != "abcd" {
+		t.Errorf("Image.Load() = %s, %v, want abcd", got, err)
+	}
+}
+
+func TestDecoder_Release(t *testing.T) {
+	data := readFile("testdata/BoxVertexColors/glTF-Binary/BoxVertexColors.glb")
+	d := NewDecoder(bytes.NewReader(data), nil)
+	doc := new(Document)
+	if err := d.Decode(doc); err != nil {
+		t.Fatalf("Decoder.Decode() error = %v", err)
+	}
+	want := append([]uint8(nil), doc.Buffers[0].Data...)
+	bin := &doc.Buffers[0].Data[0]
+	d.Release(doc)
+	if doc.Buffers[0].Data != nil {
+		t.Errorf("Decoder.Release() kept the buffer data")
+	}
+	d.Reset(bytes.NewReader(data), nil)
+	doc = new(Document)
+	if err := d.Decode(doc); err != nil {
+		t.Fatalf("Decoder.Decode() error = %v", err)
+	}
+	if &doc.Buffers[0].Data[0] != bin {
+		t.Error("Decoder.Decode() did not reuse the released BIN chunk")
+	}
+	if !bytes.Equal(doc.Buffers[0].Data, want) {
+		t.Error("Decoder.Decode() BIN chunk differs after Release")
+	}
+
+	// The buffers read from a resource are not released.
+	d.Reset(bytes.NewBufferString(`{"asset":{"version":"2.0"},"buffers":[{"byteLength":1,"uri":"a.bin"}]}`), readCallback)
+	doc = new(Document)
+	if err := d.Decode(doc); err != nil {
+		t.Fatalf("Decoder.Decode() error = %v", err)
+	}
+	d.Release(doc)
+	if doc.Buffers[0].Data == nil {
+		t.Error("Decoder.Release() released an external buffer")
+	}
+}
+
 func TestDecoder_decodeBinaryBuffer(t *testing.T) {
 	type args struct {
 		buffer *Buffer
+		length uint32
 	}
 	tests := []struct {
 		name    string
@@ -179,19 +443,152 @@ func TestDecoder_decodeBinaryBuffer(t *testing.T) {
 		args    args
 		wantErr bool
 	}{
-		{"invalidBuffer", new(Decoder), args{&Buffer{ByteLength: 0, URI: "a.bin"}}, true},
-		{"readErr", NewDecoder(bytes.NewBufferString(""), nil), args{&Buffer{ByteLength: 1, URI: "a.bin"}}, true},
-		{"invalidHeader", NewDecoder(bytes.NewBufferString("aaaaaaaa"), nil), args{&Buffer{ByteLength: 1, URI: "a.bin"}}, true},
+		{"invalidBuffer", new(Decoder), args{&Buffer{ByteLength: 0, URI: "a.bin"}, 0}, true},
+		{"shortChunk", NewDecoder(bytes.NewReader([]byte{1, 2}), nil), args{&Buffer{ByteLength: 3}, 2}, true},
+		{"longChunk", NewDecoder(bytes.NewReader([]byte{1, 2, 3, 4, 5, 6, 7, 8}), nil), args{&Buffer{ByteLength: 4}, 8}, true},
+		{"truncated", NewDecoder(bytes.NewReader([]byte{1, 2}), nil), args{&Buffer{ByteLength: 4}, 4}, true},
+		{"truncatedPadding", NewDecoder(bytes.NewReader([]byte{1, 2, 3}), nil), args{&Buffer{ByteLength: 3}, 4}, true},
+		{"chunked", NewDecoder(iotest.OneByteReader(bytes.NewReader([]byte{1, 2, 3, 0})), nil), args{&Buffer{ByteLength: 3}, 4}, false},
+		{"base", NewDecoder(bytes.NewReader([]byte{1, 2, 3, 4}), nil), args{&Buffer{ByteLength: 4}, 4}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := tt.d.decodeBinaryBuffer(tt.args.buffer); (err != nil) != tt.wantErr {
+			header := &chunkHeader{Length: tt.args.length, Type: glbChunkBIN}
+			if err := tt.d.decodeBinaryBuffer(context.Background(), tt.args.buffer, header); (err != nil) != tt.wantErr {
 				t.Errorf("Decoder.decodeBinaryBuffer() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
+// glbFile returns a GLB file made of the given JSON content and chunks,
+// which must already be padded.
+func glbFile(jsonText string, chunks ...Chunk) []uint8 {
+	for len(jsonText)%4 != 0 {
+		jsonText += " "
+	}
+	length := 20 + len(jsonText)
+	for _, c := range chunks {
+		length += 8 + len(c.Data)
+	}
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, &glbHeader{Magic: glbHeaderMagic, Version: 2, Length: uint32(length),
+		JSONHeader: chunkHeader{Length: uint32(len(jsonText)), Type: glbChunkJSON}})
+	buf.WriteString(jsonText)
+	for _, c := range chunks {
+		binary.Write(buf, binary.LittleEndian, &chunkHeader{Length: uint32(len(c.Data)), Type: c.Type})
+		buf.Write(c.Data)
+	}
+	return buf.Bytes()
+}
+
+func TestDecoder_decodeChunks(t *testing.T) {
+	const withBuffer = `{"asset":{"version":"2.0"},"buffers":[{"byteLength":4}]}`
+	const noBuffers = `{"asset":{"version":"2.0"}}`
+	bin := Chunk{Type: glbChunkBIN, Data: []uint8{1, 2, 3, 4}}
+	unknown := Chunk{Type: 0x12345678, Data: []uint8{5, 6, 7, 8}}
+	tests := []struct {
+		name       string
+		data       []uint8
+		wantChunks []Chunk
+		wantErr    bool
+	}{
+		{"bin", glbFile(withBuffer, bin), nil, false},
+		{"unknownAfterBIN", glbFile(withBuffer, bin, unknown), []Chunk{unknown}, false},
+		{"unknownOnly", glbFile(noBuffers, unknown, unknown), []Chunk{unknown, unknown}, false},
+		{"binWithoutBuffers", glbFile(noBuffers, bin, unknown), []Chunk{unknown}, false},
+		{"noBIN", glbFile(withBuffer, unknown), nil, true},
+		{"duplicatedBIN", glbFile(withBuffer, bin, bin), nil, true},
+		{"duplicatedJSON", glbFile(noBuffers, Chunk{Type: glbChunkJSON, Data: []uint8("{}  ")}), nil, true},
+		{"truncatedHeader", glbFile(withBuffer, bin)[:len(glbFile(withBuffer, bin))-8], nil, true},
+		{"trailingData", append(glbFile(withBuffer, bin), 0, 0, 0, 0), nil, true},
+		{"truncatedChunk", glbFile(noBuffers, unknown)[:len(glbFile(noBuffers, unknown))-2], nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := new(Document)
+			err := NewDecoder(bytes.NewReader(tt.data), nil).Decode(doc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Decoder.Decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := deep.Equal(doc.Chunks, tt.wantChunks); !tt.wantErr && diff != nil {
+				t.Errorf("Decoder.Decode() chunks = %v", diff)
+			}
+		})
+	}
+}
+
+func TestDecoder_decodeChunks_lengths(t *testing.T) {
+	data := glbFile(`{"asset":{"version":"2.0"}}`, Chunk{Type: 0x12345678, Data: []uint8{5, 6, 7, 8}})
+	tooLong := append([]uint8(nil), data...)
+	binary.LittleEndian.PutUint32(tooLong[len(data)-12:], 8)
+	shortFile := append([]uint8(nil), data...)
+	binary.LittleEndian.PutUint32(shortFile[8:], uint32(len(data)-6))
+	for name, data := range map[string][]uint8{"chunkTooLong": tooLong, "fileTooShort": shortFile} {
+		t.Run(name, func(t *testing.T) {
+			var e *GLBError
+			if err := NewDecoder(bytes.NewReader(data), nil).Decode(new(Document)); !errors.As(err, &e) {
+				t.Errorf("Decoder.Decode() error = %v, want *GLBError", err)
+			}
+		})
+	}
+}
+
+func TestDecoder_decodeBinaryBuffer_truncatedGLB(t *testing.T) {
+	data := readFile("testdata/BoxVertexColors/glTF-Binary/BoxVertexColors.glb")
+	err := NewDecoder(bytes.NewReader(data[:len(data)-100]), nil).Decode(new(Document))
+	var lenErr *ByteLengthError
+	if !errors.As(err, &lenErr) {
+		t.Fatalf("Decoder.Decode() error = %v, want *ByteLengthError", err)
+	}
+	if lenErr.ByteLength != 1224 || lenErr.Length != 1124 {
+		t.Errorf("Decoder.Decode() error = %v", lenErr)
+	}
+}
+
+func TestReadResource(t *testing.T) {
+	tests := []struct {
+		name       string
+		r          io.Reader
+		byteLength int
+		want       *ByteLengthError
+	}{
+		{"exact", bytes.NewReader([]byte{1, 2, 3}), 3, nil},
+		{"oneByte", iotest.OneByteReader(bytes.NewReader([]byte{1, 2, 3})), 3, nil},
+		{"half", iotest.HalfReader(bytes.NewReader([]byte{1, 2, 3})), 3, nil},
+		{"dataErr", iotest.DataErrReader(bytes.NewReader([]byte{1, 2, 3})), 3, nil},
+		{"truncated", bytes.NewReader([]byte{1, 2}), 3, &ByteLengthError{URI: "a.bin", ByteLength: 3, Length: 2}},
+		{"truncatedChunked", iotest.OneByteReader(bytes.NewReader([]byte{1})), 3, &ByteLengthError{URI: "a.bin", ByteLength: 3, Length: 1}},
+		{"empty", bytes.NewReader(nil), 3, &ByteLengthError{URI: "a.bin", ByteLength: 3, Length: 0}},
+		{"tooLong", bytes.NewReader([]byte{1, 2, 3, 4, 5}), 3, &ByteLengthError{URI: "a.bin", ByteLength: 3, Length: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := make([]uint8, tt.byteLength)
+			err := readResource(tt.r, data, "a.bin")
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("readResource() error = %v", err)
+				}
+				return
+			}
+			var lenErr *ByteLengthError
+			if !errors.As(err, &lenErr) {
+				t.Fatalf("readResource() error = %v, want *ByteLengthError", err)
+			}
+			if *lenErr != *tt.want {
+				t.Errorf("readResource() error = %v, want %v", lenErr, tt.want)
+			}
+		})
+	}
+	errRead := errors.New("read error")
+	r := io.MultiReader(bytes.NewReader([]byte{1, 2, 3}), iotest.ErrReader(errRead))
+	if err := readResource(r, make([]uint8, 3), "a.bin"); err != errRead {
+		t.Errorf("readResource() error = %v, want %v", err, errRead)
+	}
+}
+
 func TestDecoder_Decode(t *testing.T) {
 	type args struct {
 		doc *Document
@@ -208,6 +605,9 @@ func TestDecoder_Decode(t *testing.T) {
 		{"glbNoJSONChunk", NewDecoder(bytes.NewBuffer([]byte{0x67, 0x6c, 0x54, 0x46, 0x02, 0x00, 0x00, 0x00, 0x40, 0x0b, 0x00, 0x00, 0x5c, 0x06, 0x00, 0x00, 0x4a, 0x52, 0x4f, 0x4e}), readCallback), args{new(Document)}, true},
 		{"empty", NewDecoder(bytes.NewBufferString(""), nil), args{new(Document)}, true},
 		{"invalidJSON", NewDecoder(bytes.NewBufferString("{asset: {}}"), nil), args{new(Document)}, true},
+		{"trailingData", NewDecoder(bytes.NewBufferString("{\"asset\":{\"version\":\"2.0\"}}\ntrailing"), nil), args{new(Document)}, false},
+		{"truncatedJSON", NewDecoder(bytes.NewBufferString("{\"asset\":{\"version\":\"2.0\"}"), nil), args{new(Document)}, true},
+		{"notObject", NewDecoder(bytes.NewBufferString("12 {}"), nil), args{new(Document)}, true},
 		{"invalidBuffer", NewDecoder(bytes.NewBufferString("{\"buffers\": [{\"byteLength\": 0}]}"), nil), args{new(Document)}, true},
 		{"maxBuffers", NewDecoder(bytes.NewBufferString("{\"buffers\": [{\"byteLength\": 0}]}"), nil).SetQuotas(ReadQuotas{MaxBufferCount: 0}), args{new(Document)}, true},
 	}
@@ -219,3 +619,87 @@ func TestDecoder_Decode(t *testing.T) {
 		})
 	}
 }
+
+func TestDecoder_Decode_stream(t *testing.T) {
+	// Each Decode reads the next value and does not wait for the end of the stream.
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go pw.Write([]byte(`{"asset":{"version":"2.0","generator":"a\"}"}}` + "\n" + `{"asset":{"version":"2.0","generator":"b"}}`))
+	d := NewDecoder(pr, nil)
+	for _, want := range []string{`a"}`, "b"} {
+		doc := new(Document)
+		if err := d.Decode(doc); err != nil || doc.Asset.Generator != want {
+			t.Errorf("Decoder.Decode() = %s, %v, want %s", doc.Asset.Generator, err, want)
+		}
+	}
+}
+
+func Test_jsonValueScanner(t *testing.T) {
+	tests := []struct {
+		data string
+		want int
+		done bool
+	}{
+		{`{"a":[1,{"b":"}"}]} {}`, 19, true},
+		{`  {"a\"]":1}x`, 12, true},
+		{`"a\"b" 1`, 6, true},
+		{`true,`, 4, true},
+		{`12 `, 2, true},
+		{`12`, 2, false},
+		{`{"a":[1,`, 8, false},
+		{`]`, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			var s jsonValueScanner
+			if got, done := s.scan([]byte(tt.data)); got != tt.want || done != tt.done {
+				t.Errorf("jsonValueScanner.scan() = %d, %t, want %d, %t", got, done, tt.want, tt.done)
+			}
+		})
+	}
+	// The value can span several pieces.
+	var s jsonValueScanner
+	if n, done := s.scan([]byte(`{"a":"\`)); n != 7 || done {
+		t.Errorf("jsonValueScanner.scan() = %d, %t, want 7, false", n, done)
+	}
+	if n, done := s.scan([]byte(`"}"} {}`)); n != 4 || !done {
+		t.Errorf("jsonValueScanner.scan() = %d, %t, want 4, true", n, done)
+	}
+}
+
+func TestDecoder_DecodeContext(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	var read int64
+	slowCb := func(ctx context.Context, uri string) (io.ReadCloser, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			return nil, errors.New("callback context without deadline")
+		}
+		return ioutil.NopCloser(&slowReader{r: bytes.NewReader(make([]byte, 1000)), delay: time.Millisecond, read: &read}), nil
+	}
+	tests := []struct {
+		name    string
+		d       *Decoder
+		timeout time.Duration
+		want    error
+	}{
+		{"canceled", NewDecoder(bytes.NewBufferString(`{"asset": {"version": "2.0"}}`), nil), 0, context.Canceled},
+		{"deadline", NewDecoderContext(bytes.NewBufferString(buffersJSON(1, 1000)), slowCb), 20 * time.Millisecond, context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := canceled
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(context.Background(), tt.timeout)
+				defer cancel()
+			}
+			if err := tt.d.DecodeContext(ctx, new(Document)); !errors.Is(err, tt.want) {
+				t.Errorf("Decoder.DecodeContext() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if got := atomic.LoadInt64(&read); got >= 1000 {
+		t.Errorf("Decoder.DecodeContext() read %d bytes, the read was not aborted", got)
+	}
+}
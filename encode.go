@@ -1,10 +1,15 @@
 package gltf
 
 import (
+	"context"
 	"encoding/binary"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"io/fs"
+	"math"
 	"os"
+	"path"
 	"path/filepath"
 	"unsafe"
 )
@@ -13,14 +18,51 @@ import (
 // The string parameter is the URI of the resource.
 type WriteResourceCallback = func(string, int) (io.WriteCloser, error)
 
+// WriteResourceContextCallback is a WriteResourceCallback that receives the context of the encoding,
+// so long writes can be aborted as soon as it is done.
+type WriteResourceContextCallback = func(context.Context, string, int) (io.WriteCloser, error)
+
+// A WriteFS is a file system that can create files to write.
+type WriteFS interface {
+	// Create creates or truncates the named file.
+	// The name follows the same conventions as the fs.FS names.
+	Create(name string) (io.WriteCloser, error)
+}
+
+// DirWriteFS returns a WriteFS that creates the files in the directory dir.
+func DirWriteFS(dir string) WriteFS {
+	return dirWriteFS(dir)
+}
+
+type dirWriteFS string
+
+func (dir dirWriteFS) Create(name string) (io.WriteCloser, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: fs.ErrInvalid}
+	}
+	return os.Create(filepath.Join(string(dir), filepath.FromSlash(name)))
+}
+
 // Save will save a document as a glTF or a GLB file specified by name.
 func Save(doc *Document, name string, asBinary bool) error {
-	f, err := os.Create(name)
+	return SaveFS(DirWriteFS(filepath.Dir(name)), doc, filepath.Base(name), asBinary)
+}
+
+// SaveFS will save a document as a glTF or a GLB file specified by name into the file system fsys.
+// External buffers and images are created in fsys relative to the directory of name.
+// Their URIs are percent-decoded and cannot point outside of that directory.
+func SaveFS(fsys WriteFS, doc *Document, name string, asBinary bool) error {
+	f, err := fsys.Create(name)
 	if err != nil {
 		return err
 	}
+	dir := path.Dir(name)
 	cb := func(uri string, size int) (io.WriteCloser, error) {
-		return os.Create(filepath.Join(filepath.Dir(name), uri))
+		p, err := resolveURI(uri)
+		if err != nil {
+			return nil, err
+		}
+		return fsys.Create(path.Join(dir, p))
 	}
 	if err := NewEncoder(f, cb, asBinary).Encode(doc); err != nil {
 		f.Close()
@@ -32,38 +74,112 @@ func Save(doc *Document, name string, asBinary bool) error {
 // An Encoder writes a GLTF to an output stream.
 type Encoder struct {
 	w        io.Writer
-	cb       WriteResourceCallback
+	cb       WriteResourceContextCallback
 	asBinary bool
+	progress ProgressFunc
+	format   jsonFormat
 }
 
 // NewEncoder returns a new encoder that writes to w as a normal glTF file.
+// If cb is nil the external images are not written
+// and encoding a document with external buffers fails.
 func NewEncoder(w io.Writer, cb WriteResourceCallback, asBinary bool) *Encoder {
+	var ccb WriteResourceContextCallback
+	if cb != nil {
+		ccb = func(_ context.Context, uri string, size int) (io.WriteCloser, error) {
+			return cb(uri, size)
+		}
+	}
+	return NewEncoderContext(w, ccb, asBinary)
+}
+
+// NewEncoderContext returns a new encoder that writes to w
+// and writes the external resources using a context-aware callback.
+func NewEncoderContext(w io.Writer, cb WriteResourceContextCallback, asBinary bool) *Encoder {
 	return &Encoder{
 		w:        w,
 		cb:       cb,
 		asBinary: asBinary,
+		format:   jsonFormat{precision: -1},
 	}
 }
 
+// SetProgress sets a callback that reports the progress of the JSON content, the buffers and the images.
+// The return value is the same encoder.
+func (e *Encoder) SetProgress(fn ProgressFunc) *Encoder {
+	e.progress = fn
+	return e
+}
+
+// SetIndent instructs the encoder to format the JSON content as if indented by json.Indent,
+// so each element begins on a new line starting with prefix followed by one or more copies of indent.
+// Calling SetIndent("", "") disables indentation.
+// The properties with default values are omitted as in the compact form.
+// The return value is the same encoder.
+func (e *Encoder) SetIndent(prefix, indent string) *Encoder {
+	e.format.prefix, e.format.indent = prefix, indent
+	return e
+}
+
+// SetFloatPrecision sets the maximum number of digits after the decimal point
+// of the non-integer numbers of the JSON content. The trailing zeros are dropped.
+// A negative precision, the default, uses the shortest representation that round-trips each number.
+// The return value is the same encoder.
+func (e *Encoder) SetFloatPrecision(prec int) *Encoder {
+	e.format.precision = prec
+	return e
+}
+
+// SetCanonical instructs the encoder to write the JSON content in the canonical form of RFC 8785,
+// with the object keys sorted, no whitespace and the numbers in their shortest round-trip form,
+// so equal documents are always encoded into identical bytes and can be content-hashed.
+// The properties with default values are omitted as usual, so the defaults written explicitly
+// in a decoded document are dropped and -0 is written as 0.
+// It takes precedence over SetIndent and SetFloatPrecision.
+// The return value is the same encoder.
+func (e *Encoder) SetCanonical(canonical bool) *Encoder {
+	e.format.canonical = canonical
+	return e
+}
+
 // Encode writes the encoding of doc to the stream.
 func (e *Encoder) Encode(doc *Document) error {
+	return e.EncodeContext(context.Background(), doc)
+}
+
+// EncodeContext is like Encode but aborts the encoding as soon as ctx is done,
+// in which case the context error is returned.
+func (e *Encoder) EncodeContext(ctx context.Context, doc *Document) error {
 	if doc.Asset.Version == "" {
 		doc.Asset.Version = "2.0"
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	var err error
 	var externalBufferIndex = 0
+	w := &contextWriter{ctx: ctx, w: e.w}
 	if e.asBinary {
-		err = e.encodeBinary(doc)
+		err = e.encodeBinary(ctx, w, doc)
 		externalBufferIndex = 1
 	} else {
-		err = json.NewEncoder(e.w).Encode(doc)
+		pr := newProgress(e.progress, PhaseJSON, 0, "", -1)
+		if err = e.format.encode(pr.writer(w), doc); err == nil {
+			pr.finish()
+		}
 	}
 	if err != nil {
 		return err
 	}
 
 	for i := externalBufferIndex; i < len(doc.Buffers); i++ {
-		if err = e.encodeBuffer(&doc.Buffers[i]); err != nil {
+		if err = e.encodeBuffer(ctx, i, &doc.Buffers[i]); err != nil {
+			return err
+		}
+	}
+
+	for i := range doc.Images {
+		if err = e.encodeImage(ctx, i, &doc.Images[i]); err != nil {
 			return err
 		}
 	}
@@ -71,59 +187,161 @@ func (e *Encoder) Encode(doc *Document) error {
 	return err
 }
 
-func (e *Encoder) encodeBuffer(buffer *Buffer) error {
+func (e *Encoder) encodeBuffer(ctx context.Context, index int, buffer *Buffer) error {
 	if buffer.IsEmbeddedResource() {
 		return nil
 	}
-	if err := validateBufferURI(buffer.URI); err != nil {
+	data, err := buffer.LoadContext(ctx)
+	if err != nil {
 		return err
 	}
-	r, err := e.cb(buffer.URI, int(buffer.ByteLength))
+	pr := newProgress(e.progress, PhaseBuffer, index, buffer.URI, int64(len(data)))
+	return e.writeResource(ctx, indexPath("buffers", index), buffer.URI, data, pr)
+}
+
+// encodeImage writes the data of the images that point to an external resource.
+// Lazy images are loaded first.
+// Images without data, such as the ones pointing to a remote URL, are left untouched.
+func (e *Encoder) encodeImage(ctx context.Context, index int, image *Image) error {
+	if image.URI == "" || image.IsEmbeddedResource() || e.cb == nil {
+		return nil
+	}
+	data, err := image.LoadContext(ctx)
 	if err != nil {
 		return err
 	}
-	_, err = r.Write(buffer.Data)
-	if err != nil {
+	if len(data) == 0 {
+		return nil
+	}
+	pr := newProgress(e.progress, PhaseImage, index, image.URI, int64(len(data)))
+	return e.writeResource(ctx, indexPath("images", index), image.URI, data, pr)
+}
+
+func (e *Encoder) writeResource(ctx context.Context, path, uri string, data []uint8, pr *progress) error {
+	if err := validateBufferURI(path, uri); err != nil {
 		return err
 	}
-	return r.Close()
+	if e.cb == nil {
+		return &ResourceError{Path: path, URI: uri, Err: errors.New("gltf: No callback to write the resource")}
+	}
+	w, err := e.cb(ctx, uri, len(data))
+	if err != nil {
+		return &ResourceError{Path: path, URI: uri, Err: err}
+	}
+	_, err = (&contextWriter{ctx: ctx, w: pr.writer(w)}).Write(data)
+	if err != nil {
+		w.Close()
+		return &ResourceError{Path: path, URI: uri, Err: err}
+	}
+	if err = w.Close(); err != nil {
+		return &ResourceError{Path: path, URI: uri, Err: err}
+	}
+	return nil
 }
 
-func (e *Encoder) encodeBinary(doc *Document) error {
-	jsonText, err := json.Marshal(doc)
+// encodeBinary writes doc as a GLB container.
+// The JSON content is encoded once into a buffer to know its length before writing the header,
+// while the BIN chunk is written straight from the data of the first buffer.
+// The other buffers are written as external resources: merging them into the first one
+// is left to Convert with LayoutBinary, which also updates their buffer views.
+func (e *Encoder) encodeBinary(ctx context.Context, w io.Writer, doc *Document) error {
+	hasBIN := len(doc.Buffers) > 0
+	bin, err := e.binaryData(ctx, doc)
 	if err != nil {
 		return err
 	}
-	header := glbHeader{Magic: glbHeaderMagic, Version: 2, Length: 0, JSONHeader: chunkHeader{Length: 0, Type: glbChunkJSON}}
-	binHeader := chunkHeader{Length: 0, Type: glbChunkBIN}
-	var binBufferLength uint32
-	var binBuffer *Buffer
-	if len(doc.Buffers) > 0 {
-		binBuffer = &doc.Buffers[0]
-		binBufferLength = binBuffer.ByteLength
+	jsonData := getScratch()
+	defer putScratch(jsonData)
+	if err = e.format.encode(jsonData, doc); err != nil {
+		return err
+	}
+	jsonLength := jsonData.Len()
+	binLength := int64(len(bin))
+	header := glbHeader{Magic: glbHeaderMagic, Version: 2, JSONHeader: chunkHeader{Type: glbChunkJSON}}
+	total := int64(unsafe.Sizeof(header)) + paddedLength(int64(jsonLength))
+	if hasBIN {
+		total += int64(unsafe.Sizeof(chunkHeader{})) + paddedLength(binLength)
+	}
+	for _, chunk := range doc.Chunks {
+		total += int64(unsafe.Sizeof(chunkHeader{})) + paddedLength(int64(len(chunk.Data)))
+	}
+	if total > math.MaxUint32 {
+		return &GLBError{Reason: fmt.Sprintf("content length %d exceeds the 4 GiB limit", total)}
+	}
+	header.Length = uint32(total)
+	header.JSONHeader.Length = uint32(paddedLength(int64(jsonLength)))
+	if err = binary.Write(w, binary.LittleEndian, &header); err != nil {
+		return err
 	}
-	binPaddedLength := ((binBufferLength + 3) / 4) * 4
-	binPadding := make([]byte, binPaddedLength-binBufferLength)
-	binHeader.Length = binPaddedLength
 
-	header.JSONHeader.Length = uint32(((len(jsonText) + 3) / 4) * 4)
-	header.Length = uint32(unsafe.Sizeof(header)+unsafe.Sizeof(binHeader)) + header.JSONHeader.Length + binHeader.Length
-	headerPadding := make([]byte, header.JSONHeader.Length-uint32(len(jsonText)))
-	for i := range headerPadding {
-		headerPadding[i] = ' '
+	pr := newProgress(e.progress, PhaseJSON, 0, "", int64(jsonLength))
+	if _, err = pr.writer(w).Write(jsonData.Bytes()); err != nil {
+		return err
 	}
-	for i := range binPadding {
-		binPadding[i] = 0
+	if err = writePadding(w, int64(jsonLength), ' '); err != nil {
+		return err
 	}
-	err = binary.Write(e.w, binary.LittleEndian, &header)
+	pr.finish()
+
+	if hasBIN {
+		binHeader := chunkHeader{Length: uint32(paddedLength(binLength)), Type: glbChunkBIN}
+		if err = binary.Write(w, binary.LittleEndian, &binHeader); err != nil {
+			return err
+		}
+		pr = newProgress(e.progress, PhaseBuffer, 0, "", binLength)
+		if _, err = pr.writer(w).Write(bin); err != nil {
+			return err
+		}
+		pr.finish()
+		if err = writePadding(w, binLength, 0); err != nil {
+			return err
+		}
+	}
+	return e.encodeChunks(w, doc.Chunks)
+}
+
+// binaryData returns the data of the first buffer, which is stored in the BIN chunk.
+// The BIN chunk is omitted when the document has no buffers.
+func (e *Encoder) binaryData(ctx context.Context, doc *Document) ([]uint8, error) {
+	if len(doc.Buffers) == 0 {
+		return nil, nil
+	}
+	buffer := &doc.Buffers[0]
+	data, err := buffer.LoadContext(ctx)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if int64(len(data)) != int64(buffer.ByteLength) {
+		return nil, &ByteLengthError{Path: indexPath("buffers", 0), ByteLength: buffer.ByteLength, Length: int64(len(data))}
 	}
-	binary.Write(e.w, binary.LittleEndian, jsonText)
-	binary.Write(e.w, binary.LittleEndian, headerPadding)
-	binary.Write(e.w, binary.LittleEndian, &binHeader)
-	if binBuffer != nil {
-		binary.Write(e.w, binary.LittleEndian, binBuffer.Data)
+	return data, nil
+}
+
+// encodeChunks writes the unknown chunks padded to 4 bytes.
+func (e *Encoder) encodeChunks(w io.Writer, chunks []Chunk) error {
+	for _, chunk := range chunks {
+		header := chunkHeader{Length: uint32(paddedLength(int64(len(chunk.Data)))), Type: chunk.Type}
+		if err := binary.Write(w, binary.LittleEndian, &header); err != nil {
+			return err
+		}
+		if _, err := w.Write(chunk.Data); err != nil {
+			return err
+		}
+		if err := writePadding(w, int64(len(chunk.Data)), 0); err != nil {
+			return err
+		}
 	}
-	return binary.Write(e.w, binary.LittleEndian, binPadding)
+	return nil
+}
+
+// paddedLength returns n rounded up to the 4-byte alignment required by the GLB chunks.
+func paddedLength(n int64) int64 {
+	return (n + 3) &^ 3
+}
+
+// writePadding writes the bytes needed to align a chunk of length n to 4 bytes.
+func writePadding(w io.Writer, n int64, pad uint8) error {
+	padding := [3]uint8{pad, pad, pad}
+	_, err := w.Write(padding[:paddedLength(n)-n])
+	return err
 }
@@ -2,10 +2,17 @@ package gltf
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/go-test/deep"
 )
@@ -16,6 +23,13 @@ type writeCloser struct {
 
 func (w *writeCloser) Close() error { return nil }
 
+type memWriteFS map[string]*bytes.Buffer
+
+func (m memWriteFS) Create(name string) (io.WriteCloser, error) {
+	m[name] = new(bytes.Buffer)
+	return &writeCloser{m[name]}, nil
+}
+
 func saveMemory(doc *Document, asBinary bool) (*Decoder, error) {
 	buff := new(bytes.Buffer)
 	chunks := make(map[string]*bytes.Buffer)
@@ -27,7 +41,10 @@ func saveMemory(doc *Document, asBinary bool) (*Decoder, error) {
 		return nil, err
 	}
 	rcb := func(uri string) (io.ReadCloser, error) {
-		return ioutil.NopCloser(chunks[uri]), nil
+		if chunk, ok := chunks[uri]; ok {
+			return ioutil.NopCloser(chunk), nil
+		}
+		return nil, os.ErrNotExist
 	}
 	return NewDecoder(buff, rcb), nil
 }
@@ -158,3 +175,303 @@ func TestEncoder_Encode(t *testing.T) {
 		}
 	}
 }
+
+func TestSaveFS(t *testing.T) {
+	doc := &Document{
+		Asset:   Asset{Version: "2.0"},
+		Buffers: []Buffer{{ByteLength: 3, URI: "bin/a.bin", Data: []uint8{1, 2, 3}}},
+		Images:  []Image{{URI: "img/a.png", Data: []uint8{4, 5}}},
+		Scene:   -1,
+	}
+	fsys := make(memWriteFS)
+	if err := SaveFS(fsys, doc, "models/a.gltf", false); err != nil {
+		t.Fatalf("SaveFS() error = %v", err)
+	}
+	mfs := make(fstest.MapFS)
+	for name, b := range fsys {
+		mfs[name] = &fstest.MapFile{Data: b.Bytes()}
+	}
+	for _, name := range []string{"models/bin/a.bin", "models/img/a.png"} {
+		if _, ok := mfs[name]; !ok {
+			t.Fatalf("SaveFS() did not create %s", name)
+		}
+	}
+	got, err := OpenFS(mfs, "models/a.gltf")
+	if err != nil {
+		t.Fatalf("OpenFS() error = %v", err)
+	}
+	if diff := deep.Equal(got, doc); diff != nil {
+		t.Errorf("SaveFS() = %v", diff)
+	}
+	if err := SaveFS(fsys, &Document{Buffers: []Buffer{{ByteLength: 1, URI: "../a.bin", Data: []uint8{1}}}}, "a.gltf", false); err == nil {
+		t.Errorf("SaveFS() expected invalid URI error")
+	}
+}
+
+func TestEncoder_Encode_lazy(t *testing.T) {
+	doc := &Document{
+		Asset:   Asset{Version: "2.0"},
+		Buffers: []Buffer{{ByteLength: 3, URI: "a.bin", loader: func(context.Context) ([]uint8, error) { return []uint8{1, 2, 3}, nil }}},
+		Images:  []Image{{URI: "a.png", loader: func(context.Context) ([]uint8, error) { return []uint8{4}, nil }}},
+	}
+	fsys := make(memWriteFS)
+	if err := SaveFS(fsys, doc, "a.gltf", false); err != nil {
+		t.Fatalf("SaveFS() error = %v", err)
+	}
+	if got := fsys["a.bin"].Bytes(); !bytes.Equal(got, []uint8{1, 2, 3}) {
+		t.Errorf("SaveFS() a.bin = %v, want [1 2 3]", got)
+	}
+	if got := fsys["a.png"].Bytes(); !bytes.Equal(got, []uint8{4}) {
+		t.Errorf("SaveFS() a.png = %v, want [4]", got)
+	}
+	doc.Buffers = []Buffer{{ByteLength: 1, URI: "b.bin", loader: func(context.Context) ([]uint8, error) { return nil, errors.New("") }}}
+	if err := SaveFS(fsys, doc, "b.gltf", false); err == nil {
+		t.Errorf("SaveFS() expected load error")
+	}
+}
+
+func TestEncoder_Encode_chunks(t *testing.T) {
+	doc := &Document{
+		Asset:   Asset{Version: "2.0"},
+		Buffers: []Buffer{{ByteLength: 3, Data: []uint8{1, 2, 3}}},
+		Scene:   -1,
+		Chunks:  []Chunk{{Type: 0x12345678, Data: []uint8{4, 5, 6, 7}}, {Type: 0x87654321, Data: []uint8{8, 9}}},
+	}
+	buf := new(bytes.Buffer)
+	if err := NewEncoder(buf, nil, true).Encode(doc); err != nil {
+		t.Fatalf("Encoder.Encode() error = %v", err)
+	}
+	got := new(Document)
+	if err := NewDecoder(buf, nil).Decode(got); err != nil {
+		t.Fatalf("Decoder.Decode() error = %v", err)
+	}
+	if len(got.Chunks) != 2 {
+		t.Fatalf("Encoder.Encode() chunks = %v, want 2 chunks", got.Chunks)
+	}
+	if diff := deep.Equal(got.Chunks[0], doc.Chunks[0]); diff != nil {
+		t.Errorf("Encoder.Encode() aligned chunk = %v", diff)
+	}
+	// The GLB format does not keep the length of an unaligned chunk, so it is decoded with its padding.
+	if want := []uint8{8, 9, 0, 0}; !bytes.Equal(got.Chunks[1].Data, want) {
+		t.Errorf("Encoder.Encode() unaligned chunk = %v, want %v", got.Chunks[1].Data, want)
+	}
+	got.Chunks, doc.Chunks = nil, nil
+	if diff := deep.Equal(got, doc); diff != nil {
+		t.Errorf("Encoder.Encode() = %v", diff)
+	}
+}
+
+func TestEncoder_SetIndent(t *testing.T) {
+	node := NewNode()
+	node.Translation = [3]float64{0.123456789, 1, 0}
+	doc := &Document{
+		Asset:     Asset{Version: "2.0"},
+		Scene:     -1,
+		Nodes:     []Node{*node},
+		Materials: []Material{{Name: "m", AlphaMode: Opaque, AlphaCutoff: 0.5, Extras: map[string]interface{}{"scale": []interface{}{1.0, 1.0, 1.0}}}},
+	}
+	for _, asBinary := range []bool{false, true} {
+		buf := new(bytes.Buffer)
+		if err := NewEncoder(buf, nil, asBinary).SetIndent("", "  ").SetFloatPrecision(3).Encode(doc); err != nil {
+			t.Fatalf("Encoder.Encode() error = %v", err)
+		}
+		out := buf.String()
+		for _, want := range []string{"\n  \"nodes\": [\n", "0.123,", "\"scale\": [\n"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("Encoder.Encode() = %s, want it to contain %q", out, want)
+			}
+		}
+		for _, unwanted := range []string{"0.1234", "\"matrix\"", "\"rotation\"", "\"alphaMode\""} {
+			if strings.Contains(out, unwanted) {
+				t.Errorf("Encoder.Encode() = %s, want it not to contain %q", out, unwanted)
+			}
+		}
+		got := new(Document)
+		if err := NewDecoder(buf, nil).Decode(got); err != nil {
+			t.Fatalf("Decoder.Decode() error = %v", err)
+		}
+		if got.Nodes[0].Translation != [3]float64{0.123, 1, 0} {
+			t.Errorf("Encoder.Encode() translation = %v", got.Nodes[0].Translation)
+		}
+	}
+}
+
+// encodeCanonical encodes doc in canonical form and returns the output and the external resources.
+func encodeCanonical(t *testing.T, doc *Document, asBinary bool) ([]byte, memWriteFS) {
+	t.Helper()
+	fsys := make(memWriteFS)
+	buf := new(bytes.Buffer)
+	cb := func(uri string, size int) (io.WriteCloser, error) {
+		return fsys.Create(uri)
+	}
+	if err := NewEncoder(buf, cb, asBinary).SetCanonical(true).Encode(doc); err != nil {
+		t.Fatalf("Encoder.Encode() error = %v", err)
+	}
+	return buf.Bytes(), fsys
+}
+
+func TestEncoder_SetCanonical(t *testing.T) {
+	var files []string
+	for _, pattern := range []string{"testdata/*/*/*.gltf", "testdata/*/*/*.glb"} {
+		matches, _ := filepath.Glob(pattern)
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		t.Fatal("no testdata files")
+	}
+	for _, name := range files {
+		t.Run(name, func(t *testing.T) {
+			doc, err := Open(name)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			asBinary := filepath.Ext(name) == ".glb"
+			first, resources := encodeCanonical(t, doc, asBinary)
+			cb := func(uri string) (io.ReadCloser, error) {
+				if b, ok := resources[uri]; ok {
+					return ioutil.NopCloser(bytes.NewReader(b.Bytes())), nil
+				}
+				return nil, os.ErrNotExist
+			}
+			doc = new(Document)
+			if err := NewDecoder(bytes.NewReader(first), cb).Decode(doc); err != nil {
+				t.Fatalf("Decoder.Decode() error = %v", err)
+			}
+			second, resources2 := encodeCanonical(t, doc, asBinary)
+			if !bytes.Equal(first, second) {
+				t.Errorf("Encoder.Encode() is not stable:\n%s\n%s", first, second)
+			}
+			for uri, b := range resources {
+				if !bytes.Equal(b.Bytes(), resources2[uri].Bytes()) {
+					t.Errorf("Encoder.Encode() resource %s is not stable", uri)
+				}
+			}
+		})
+	}
+}
+
+type failWriter struct {
+	n int
+}
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n := w.n
+		w.n = 0
+		return n, errors.New("write failed")
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestEncoder_Encode_binary(t *testing.T) {
+	doc := &Document{
+		Asset:   Asset{Version: "2.0"},
+		Buffers: []Buffer{{ByteLength: 5, Data: []uint8{1, 2, 3, 4, 5}}},
+		Scene:   -1,
+		Chunks:  []Chunk{{Type: 0x12345678, Data: []uint8{6}}},
+	}
+	buf := new(bytes.Buffer)
+	if err := NewEncoder(buf, nil, true).Encode(doc); err != nil {
+		t.Fatalf("Encoder.Encode() error = %v", err)
+	}
+	data := buf.Bytes()
+	if got := binary.LittleEndian.Uint32(data[8:]); int(got) != len(data) {
+		t.Errorf("Encoder.Encode() header length = %d, want %d", got, len(data))
+	}
+	for n := 0; n < len(data); n++ {
+		if err := NewEncoder(&failWriter{n: n}, nil, true).Encode(doc); err == nil {
+			t.Errorf("Encoder.Encode() failing after %d bytes expected error", n)
+		}
+	}
+	got := new(Document)
+	if err := NewDecoder(bytes.NewReader(data), nil).Decode(got); err != nil {
+		t.Fatalf("Decoder.Decode() error = %v", err)
+	}
+	if !bytes.Equal(got.Buffers[0].Data, doc.Buffers[0].Data) {
+		t.Errorf("Encoder.Encode() BIN = %v, want %v", got.Buffers[0].Data, doc.Buffers[0].Data)
+	}
+
+	buf.Reset()
+	if err := NewEncoder(buf, nil, true).Encode(&Document{Scene: -1}); err != nil {
+		t.Fatalf("Encoder.Encode() error = %v", err)
+	}
+	if got := binary.LittleEndian.Uint32(buf.Bytes()[8:]); int(got) != buf.Len() {
+		t.Errorf("Encoder.Encode() header length = %d, want %d", got, buf.Len())
+	}
+
+	// The JSON content is marshalled once.
+	extras := new(countMarshaler)
+	doc.Extras = extras
+	if err := NewEncoder(new(bytes.Buffer), nil, true).Encode(doc); err != nil || *extras != 1 {
+		t.Errorf("Encoder.Encode() marshalled the JSON %d times, %v", *extras, err)
+	}
+
+	doc.Buffers[0].ByteLength = 6
+	var e *ByteLengthError
+	if err := NewEncoder(new(bytes.Buffer), nil, true).Encode(doc); !errors.As(err, &e) {
+		t.Errorf("Encoder.Encode() error = %v, want ByteLengthError", err)
+	}
+}
+
+func TestEncoder_Encode_nilCallback(t *testing.T) {
+	doc, err := Open("testdata/Cube/glTF/Cube.gltf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if err := NewEncoder(buf, nil, true).Encode(doc); err != nil {
+		t.Fatalf("Encoder.Encode() error = %v", err)
+	}
+	got := new(Document)
+	if err := NewDecoder(buf, nil).Decode(got); err != nil {
+		t.Fatalf("Decoder.Decode() error = %v", err)
+	}
+	if !bytes.Equal(got.Buffers[0].Data, doc.Buffers[0].Data) || got.Images[0].Data != nil {
+		t.Errorf("Encoder.Encode() = %v, %v, want the BIN chunk without the images", got.Buffers, got.Images)
+	}
+	var e *ResourceError
+	if err := NewEncoder(new(bytes.Buffer), nil, false).Encode(doc); !errors.As(err, &e) || e.Path != "buffers[0]" {
+		t.Errorf("Encoder.Encode() error = %v, want *ResourceError at buffers[0]", err)
+	}
+}
+
+type countMarshaler int
+
+func (m *countMarshaler) MarshalJSON() ([]byte, error) {
+	*m++
+	return []byte("1"), nil
+}
+
+type cancelWriter struct {
+	cancel  context.CancelFunc
+	written int
+}
+
+func (w *cancelWriter) Write(p []byte) (int, error) {
+	w.written += len(p)
+	w.cancel()
+	return len(p), nil
+}
+
+func (w *cancelWriter) Close() error { return nil }
+
+func TestEncoder_EncodeContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := &cancelWriter{cancel: cancel}
+	cb := func(ctx context.Context, uri string, size int) (io.WriteCloser, error) {
+		return w, nil
+	}
+	doc := &Document{Buffers: []Buffer{{ByteLength: 3 * maxChunk, URI: "a.bin", Data: make([]uint8, 3*maxChunk)}}}
+	err := NewEncoderContext(new(bytes.Buffer), cb, false).EncodeContext(ctx, doc)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Encoder.EncodeContext() error = %v, want %v", err, context.Canceled)
+	}
+	if w.written != maxChunk {
+		t.Errorf("Encoder.EncodeContext() written = %d, want %d", w.written, maxChunk)
+	}
+	if err := NewEncoder(new(bytes.Buffer), nil, false).EncodeContext(ctx, new(Document)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Encoder.EncodeContext() error = %v, want %v", err, context.Canceled)
+	}
+}
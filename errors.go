@@ -0,0 +1,214 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A QuotaError is returned when a document exceeds one of the limits defined in ReadQuotas.
+type QuotaError struct {
+	Quota string // Name of the exceeded ReadQuotas field, such as "MaxMemoryAllocation".
+	Path  string // JSON path of the offending object, such as "buffers[2]". It is empty for the whole file.
+	Limit int    // Value of the quota.
+	Value int    // Value that exceeded the quota.
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("gltf: Quota exceeded%s, %d > %s (%d)", atPath(e.Path), e.Value, e.Quota, e.Limit)
+}
+
+// A GLBError is returned when a binary glTF file does not follow the GLB container format.
+type GLBError struct {
+	Reason string
+	Err    error // Underlying read error, if any.
+}
+
+func (e *GLBError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("gltf: Invalid GLB, %s: %v", e.Reason, e.Err)
+	}
+	return "gltf: Invalid GLB, " + e.Reason
+}
+
+func (e *GLBError) Unwrap() error { return e.Err }
+
+// A SyntaxError is returned when the JSON content of a document is malformed.
+type SyntaxError struct {
+	Offset int64  // Byte offset of the error in the JSON content.
+	Path   string // JSON path of the value being read when the error occurred, such as "nodes[3].name".
+	Err    error  // Underlying *json.SyntaxError.
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("gltf: Invalid JSON%s at offset %d: %v", atPath(e.Path), e.Offset, e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error { return e.Err }
+
+// A StrictError is returned by a strict Decoder when a property is unknown, duplicated or of the wrong type.
+type StrictError struct {
+	Path   string // JSON path of the property, such as "bufferViews[0].byteOfset".
+	Reason string
+}
+
+func (e *StrictError) Error() string {
+	return fmt.Sprintf("gltf: Invalid property %s, %s", e.Path, e.Reason)
+}
+
+// A URIError is returned when the URI of an external resource is not valid or not allowed.
+type URIError struct {
+	Path string // JSON path of the object holding the URI, such as "images[0]".
+	URI  string
+	Err  error // Underlying parsing or decoding error, if any.
+}
+
+func (e *URIError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("gltf: Invalid URI '%s'%s: %v", e.URI, atPath(e.Path), e.Err)
+	}
+	return fmt.Sprintf("gltf: Invalid URI '%s'%s", e.URI, atPath(e.Path))
+}
+
+func (e *URIError) Unwrap() error { return e.Err }
+
+// A ResourceError is returned when an external resource cannot be read or written.
+// The underlying error is the one returned by the resource callback or by the resource itself,
+// so a missing resource can be detected using errors.Is(err, fs.ErrNotExist).
+type ResourceError struct {
+	Path string // JSON path of the object holding the URI, such as "buffers[1]".
+	URI  string
+	Err  error
+}
+
+func (e *ResourceError) Error() string {
+	return fmt.Sprintf("gltf: Resource '%s'%s: %v", e.URI, atPath(e.Path), e.Err)
+}
+
+func (e *ResourceError) Unwrap() error { return e.Err }
+
+// A ByteLengthError is returned when a buffer resource holds less or more bytes than its declared byteLength.
+type ByteLengthError struct {
+	Path       string // JSON path of the buffer, such as "buffers[0]".
+	URI        string // URI of the resource. It is empty for the GLB BIN chunk.
+	ByteLength uint32 // Declared byteLength of the buffer.
+	Length     int64  // Number of bytes found. When the resource is too long it is only counted up to the first extra byte.
+}
+
+func (e *ByteLengthError) Error() string {
+	name := "GLB BIN chunk"
+	if e.URI != "" {
+		name = fmt.Sprintf("buffer '%s'", e.URI)
+	}
+	if e.Length < int64(e.ByteLength) {
+		return fmt.Sprintf("gltf: %s%s is truncated, got %d of %d bytes", name, atPath(e.Path), e.Length, e.ByteLength)
+	}
+	return fmt.Sprintf("gltf: %s%s is longer than byteLength %d", name, atPath(e.Path), e.ByteLength)
+}
+
+// A ValidationError describes a property that does not follow the glTF specification.
+type ValidationError struct {
+	Path      string      // JSON path of the property, such as "accessors[0].componentType".
+	Namespace string      // Go namespace of the field, such as "Document.Accessors[0].ComponentType". It is only set by Validate.
+	Tag       string      // Name of the broken rule, such as "required" or "oneof".
+	Value     interface{} // Invalid value.
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("gltf: Invalid %s value %v, failed '%s' rule", e.Path, e.Value, e.Tag)
+}
+
+// ValidationErrors is returned by Validate and contains one error per invalid property.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func atPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	return " at " + path
+}
+
+func indexPath(name string, i int) string {
+	return name + "[" + strconv.Itoa(i) + "]"
+}
+
+// newSyntaxError converts a *json.SyntaxError into a *SyntaxError.
+// Other errors are returned untouched.
+func newSyntaxError(err error, data []byte) error {
+	if e, ok := err.(*json.SyntaxError); ok {
+		return &SyntaxError{Offset: e.Offset, Path: jsonPath(data, e.Offset), Err: e}
+	}
+	return err
+}
+
+// jsonPath returns the path of the JSON value that is being read at offset, such as "buffers[0].byteLength".
+func jsonPath(data []byte, offset int64) string {
+	if offset < int64(len(data)) {
+		data = data[:offset]
+	}
+	type frame struct {
+		key     string // Current member of an object.
+		index   int    // Current element of an array.
+		isArray bool
+		wantKey bool // An object is between members.
+	}
+	var stack []*frame
+	endValue := func() {
+		if n := len(stack); n > 0 {
+			if stack[n-1].isArray {
+				stack[n-1].index++
+			} else {
+				stack[n-1].wantKey = true
+			}
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		if n := len(stack); n > 0 && !stack[n-1].isArray && stack[n-1].wantKey {
+			if key, ok := tok.(string); ok {
+				stack[n-1].key, stack[n-1].wantKey = key, false
+				continue
+			}
+		}
+		if delim, ok := tok.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			endValue()
+			continue
+		}
+		switch tok {
+		case json.Delim('{'):
+			stack = append(stack, &frame{wantKey: true})
+		case json.Delim('['):
+			stack = append(stack, &frame{isArray: true})
+		default:
+			endValue()
+		}
+	}
+	var sb strings.Builder
+	for _, f := range stack {
+		switch {
+		case f.isArray:
+			sb.WriteString("[" + strconv.Itoa(f.index) + "]")
+		case !f.isArray && !f.wantKey:
+			if sb.Len() > 0 {
+				sb.WriteByte('.')
+			}
+			sb.WriteString(f.key)
+		}
+	}
+	return sb.String()
+}
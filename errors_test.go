@@ -0,0 +1,105 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestJSONPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		offset int64
+		want   string
+	}{
+		{"empty", ``, 0, ""},
+		{"root", `{"asset": `, 100, "asset"},
+		{"array", `{"buffers": [{"byteLength": 1}, {"byteLength": `, 100, "buffers[1].byteLength"},
+		{"betweenMembers", `{"buffers": [{"byteLength": 1, `, 100, "buffers[0]"},
+		{"nested", `{"nodes": [{}, {"extras": {"a": [1, [2, `, 100, "nodes[1].extras.a[1][1]"},
+		{"closed", `{"nodes": [{"name": "a"}], "meshes": `, 100, "meshes"},
+		{"offset", `{"nodes": [{"name": "a"}], "meshes": []}`, 20, "nodes[0].name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jsonPath([]byte(tt.data), tt.offset); got != tt.want {
+				t.Errorf("jsonPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecoder_Decode_errorTypes(t *testing.T) {
+	notFound := func(uri string) (io.ReadCloser, error) { return nil, os.ErrNotExist }
+	tests := []struct {
+		name  string
+		d     *Decoder
+		check func(error) bool
+	}{
+		{"quota", NewDecoder(bytes.NewBufferString(`{"buffers": [{"byteLength": 1, "uri": "a.bin"}]}`), readCallback).SetQuotas(ReadQuotas{MaxBufferCount: 0}), func(err error) bool {
+			var e *QuotaError
+			return errors.As(err, &e) && e.Quota == "MaxBufferCount" && e.Value == 1
+		}},
+		{"glb", NewDecoder(bytes.NewBuffer([]byte{0x67, 0x6c, 0x54, 0x46, 0x02, 0x00, 0x00, 0x00, 0x40, 0x0b, 0x00, 0x00, 0x5c, 0x06, 0x00, 0x00, 0x4a, 0x52, 0x4f, 0x4e}), readCallback), func(err error) bool {
+			var e *GLBError
+			return errors.As(err, &e)
+		}},
+		{"syntax", NewDecoder(bytes.NewBufferString(`{"buffers": [{"byteLength": 1, "uri": a.bin}]}`), readCallback), func(err error) bool {
+			var e *SyntaxError
+			var jsonErr *json.SyntaxError
+			return errors.As(err, &e) && e.Offset == 39 && e.Path == "buffers[0].uri" && errors.As(err, &jsonErr)
+		}},
+		{"uri", NewDecoder(bytes.NewBufferString(`{"buffers": [{"byteLength": 1, "uri": "a.bin"}, {"byteLength": 1, "uri": "/a.bin"}]}`), readCallback), func(err error) bool {
+			var e *URIError
+			return errors.As(err, &e) && e.Path == "buffers[1]" && e.URI == "/a.bin"
+		}},
+		{"dataURI", NewDecoder(bytes.NewBufferString(`{"buffers": [{"byteLength": 1, "uri": "data:application/octet-stream;base64,!!!!"}]}`), readCallback), func(err error) bool {
+			var e *URIError
+			return errors.As(err, &e) && e.Path == "buffers[0]" && e.Err != nil
+		}},
+		{"dataURIQuota", NewDecoder(bytes.NewBufferString(`{"images": [{"uri": "data:image/png;base64,!!!!!!!!"}]}`), readCallback).SetQuotas(ReadQuotas{MaxMemoryAllocation: 5}), func(err error) bool {
+			// The payload is invalid, so the quota error proves it is checked before decoding.
+			var e *QuotaError
+			return errors.As(err, &e) && e.Path == "images[0]" && e.Value == 6
+		}},
+		{"notFound", NewDecoder(bytes.NewBufferString(`{"images": [{"uri": "a.png"}]}`), notFound), func(err error) bool {
+			var e *ResourceError
+			return errors.As(err, &e) && e.Path == "images[0]" && errors.Is(err, os.ErrNotExist)
+		}},
+		{"byteLength", NewDecoder(bytes.NewBufferString(`{"buffers": [{"byteLength": 2, "uri": "a.bin"}]}`), readCallback), func(err error) bool {
+			var e *ByteLengthError
+			return errors.As(err, &e) && e.Path == "buffers[0]" && e.Length == 1
+		}},
+		{"invalid", NewDecoder(bytes.NewBufferString(`{"buffers": [{"byteLength": 0, "uri": "a.bin"}]}`), readCallback), func(err error) bool {
+			var e *ValidationError
+			return errors.As(err, &e) && e.Path == "buffers[0].byteLength"
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.d.Decode(new(Document)); !tt.check(err) {
+				t.Errorf("Decoder.Decode() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestEncoder_Encode_errorTypes(t *testing.T) {
+	failing := func(uri string, size int) (io.WriteCloser, error) { return nil, os.ErrPermission }
+	doc := &Document{Buffers: []Buffer{{ByteLength: 1, URI: "a.bin", Data: []uint8{1}}}}
+	err := NewEncoder(new(bytes.Buffer), failing, false).Encode(doc)
+	var e *ResourceError
+	if !errors.As(err, &e) || e.Path != "buffers[0]" || !errors.Is(err, os.ErrPermission) {
+		t.Errorf("Encoder.Encode() unexpected error = %v", err)
+	}
+	doc.Buffers[0].URI = "../a.bin"
+	err = NewEncoder(new(bytes.Buffer), failing, false).Encode(doc)
+	var uriErr *URIError
+	if !errors.As(err, &uriErr) || uriErr.Path != "buffers[0]" {
+		t.Errorf("Encoder.Encode() unexpected error = %v", err)
+	}
+}
@@ -65,18 +65,15 @@ func (p *PBRSpecularGlossiness) UnmarshalJSON(data []byte) error {
 // MarshalJSON marshal the pbr with the correct default values.
 func (p *PBRSpecularGlossiness) MarshalJSON() ([]byte, error) {
 	type alias PBRSpecularGlossiness
-	out, err := json.Marshal(&struct{ *alias }{alias: (*alias)(p)})
-	if err == nil {
-		if p.GlossinessFactor == 1 {
-			out = removeProperty([]byte(`"glossinessFactor":1`), out)
-		}
-		if p.DiffuseFactor == [4]float64{1, 1, 1, 1} {
-			out = removeProperty([]byte(`"diffuseFactor":[1,1,1,1]`), out)
-		}
-		if p.SpecularFactor == [3]float64{1, 1, 1} {
-			out = removeProperty([]byte(`"specularFactor":[1,1,1]`), out)
-		}
-		out = sanitizeJSON(out)
-	}
-	return out, err
+	return json.Marshal(&struct {
+		DiffuseFactor    *[4]float64 `json:"diffuseFactor,omitempty"`
+		SpecularFactor   *[3]float64 `json:"specularFactor,omitempty"`
+		GlossinessFactor *float64    `json:"glossinessFactor,omitempty"`
+		*alias
+	}{
+		DiffuseFactor:    omitDefault(&p.DiffuseFactor, [4]float64{1, 1, 1, 1}),
+		SpecularFactor:   omitDefault(&p.SpecularFactor, [3]float64{1, 1, 1}),
+		GlossinessFactor: omitDefault(&p.GlossinessFactor, 1),
+		alias:            (*alias)(p),
+	})
 }
@@ -0,0 +1,260 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// jsonFormat controls how the JSON content of a document is written.
+type jsonFormat struct {
+	prefix, indent string
+	precision      int
+	canonical      bool
+}
+
+// encode writes the JSON encoding of doc to w followed by a newline.
+// The keys of the structs are written in declaration order and the map keys are sorted,
+// so the output is stable for a given document.
+// The documents decoded by a preserving Decoder are merged with their original JSON content
+// and, unless an indentation is set, indented as it was.
+func (f *jsonFormat) encode(w io.Writer, doc *Document) error {
+	if doc.raw == nil && !f.canonical && f.precision < 0 {
+		enc := json.NewEncoder(w)
+		enc.SetIndent(f.prefix, f.indent)
+		return enc.Encode(doc)
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	prefix, indent := f.prefix, f.indent
+	switch {
+	case f.canonical:
+		data, err = canonicalJSON(data)
+		prefix, indent = "", ""
+	case doc.raw != nil:
+		data, err = preserveJSON(doc.raw, data)
+		if prefix == "" && indent == "" {
+			indent = detectIndent(doc.raw)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if f.precision >= 0 && !f.canonical {
+		data = roundFloats(data, f.precision)
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, len(data)+len(data)/2))
+	if prefix != "" || indent != "" {
+		if err = json.Indent(buf, data, prefix, indent); err != nil {
+			return err
+		}
+	} else {
+		buf.Write(data)
+	}
+	buf.WriteByte('\n')
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// roundFloats rewrites the non-integer numbers of the valid JSON data
+// with at most prec digits after the decimal point, dropping the trailing zeros.
+// Integer numbers are kept as they are.
+func roundFloats(data []byte, prec int) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			if c == '\\' {
+				out = append(out, c)
+				i++
+				c = data[i]
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '-' || (c >= '0' && c <= '9'):
+			j, isFloat := i, false
+			for ; j < len(data) && isNumberByte(data[j]); j++ {
+				isFloat = isFloat || data[j] == '.' || data[j] == 'e' || data[j] == 'E'
+			}
+			if isFloat {
+				out = appendFloat(out, data[i:j], prec)
+			} else {
+				out = append(out, data[i:j]...)
+			}
+			i = j - 1
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func isNumberByte(c byte) bool {
+	return (c >= '0' && c <= '9') || c == '-' || c == '+' || c == '.' || c == 'e' || c == 'E'
+}
+
+func appendFloat(out, number []byte, prec int) []byte {
+	f, err := strconv.ParseFloat(string(number), 64)
+	if err != nil {
+		return append(out, number...)
+	}
+	s := strconv.FormatFloat(f, 'f', prec, 64)
+	if prec > 0 {
+		s = trimZeros(s)
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return append(out, s...)
+}
+
+func trimZeros(s string) string {
+	i := len(s)
+	for s[i-1] == '0' {
+		i--
+	}
+	if s[i-1] == '.' {
+		i--
+	}
+	return s[:i]
+}
+
+// canonicalJSON rewrites the valid JSON data in the canonical form defined by RFC 8785:
+// no insignificant whitespace, object members sorted by the UTF-16 code units of their keys
+// and numbers formatted as the shortest text that round-trips their float64 value.
+func canonicalJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, len(data)))
+	if err := writeCanonical(buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeCanonical(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sortUTF16(keys)
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, k)
+			buf.WriteByte(':')
+			if err := writeCanonical(buf, v[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, e); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case json.Number:
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(canonicalNumber(f))
+	case string:
+		writeCanonicalString(buf, v)
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case nil:
+		buf.WriteString("null")
+	}
+	return nil
+}
+
+// canonicalNumber formats f as ECMAScript does, which is the format required by RFC 8785.
+func canonicalNumber(f float64) string {
+	if f == 0 {
+		return "0"
+	}
+	if abs := math.Abs(f); abs >= 1e-6 && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	s := strconv.FormatFloat(f, 'e', -1, 64)
+	// Go pads the exponent to two digits, such as 1e-07.
+	i := strings.IndexByte(s, 'e')
+	mantissa, sign, exp := s[:i], s[i+1], strings.TrimLeft(s[i+2:], "0")
+	return mantissa + "e" + string(sign) + exp
+}
+
+// sortUTF16 sorts keys by their UTF-16 code units, as RFC 8785 requires.
+// It differs from the UTF-8 byte order for the characters outside of the Basic Multilingual Plane,
+// whose surrogates sort before U+E000 to U+FFFF.
+func sortUTF16(keys []string) {
+	units := make(map[string][]uint16, len(keys))
+	for _, k := range keys {
+		units[k] = utf16.Encode([]rune(k))
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := units[keys[i]], units[keys[j]]
+		for n := 0; n < len(a) && n < len(b); n++ {
+			if a[n] != b[n] {
+				return a[n] < b[n]
+			}
+		}
+		return len(a) < len(b)
+	})
+}
+
+// writeCanonicalString writes s quoted as RFC 8785 requires: only the quotation mark, the reverse solidus
+// and the control characters are escaped, using the short escapes when they exist.
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r == '\b':
+			buf.WriteString(`\b`)
+		case r == '\f':
+			buf.WriteString(`\f`)
+		case r == '\n':
+			buf.WriteString(`\n`)
+		case r == '\r':
+			buf.WriteString(`\r`)
+		case r == '\t':
+			buf.WriteString(`\t`)
+		case r < 0x20:
+			buf.WriteString(`\u00`)
+			buf.WriteByte(hex[r>>4])
+			buf.WriteByte(hex[r&0xf])
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+}
@@ -0,0 +1,58 @@
+package gltf
+
+import (
+	"testing"
+)
+
+func Test_roundFloats(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		prec int
+		want string
+	}{
+		{"empty", `{}`, 3, `{}`},
+		{"integers", `{"a":[1,-20,300]}`, 0, `{"a":[1,-20,300]}`},
+		{"floats", `[0.800000011920929,-0.5,1.25e-7,2E+2]`, 3, `[0.8,-0.5,0,200]`},
+		{"zeroPrecision", `[0.5,1.6,-0.2]`, 0, `[0,2,0]`},
+		{"strings", `{"1.123456":"0.123456","b":"\"1.123456"}`, 2, `{"1.123456":"0.123456","b":"\"1.123456"}`},
+		{"nested", `{"a":{"b":[1.123456,{"c":2.5}]},"d":true,"e":null}`, 4, `{"a":{"b":[1.1235,{"c":2.5}]},"d":true,"e":null}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(roundFloats([]byte(tt.data), tt.prec)); got != tt.want {
+				t.Errorf("roundFloats() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_canonicalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{"empty", `{}`, `{}`, false},
+		{"whitespace", "{ \"a\" : [ 1 , 2 ] ,\n\"b\":null }", `{"a":[1,2],"b":null}`, false},
+		{"sorted", `{"b":{"d":true,"c":false},"a":1,"B":2}`, `{"B":2,"a":1,"b":{"c":false,"d":true}}`, false},
+		{"numbers", `[1.0,-0,0.800000011920929,1E3,1e21,1e-7,0.000001,123456789012,-1.5e-10]`, `[1,0,0.800000011920929,1000,1e+21,1e-7,0.000001,123456789012,-1.5e-10]`, false},
+		{"strings", `["<a&b>","\u00e9","\"\\\n"]`, `["<a&b>","é","\"\\\n"]`, false},
+		{"controls", `["\u0000\u001f\b\f\t\r\/"]`, `["\u0000\u001f\b\f\t\r/"]`, false},
+		{"lineSeparators", `["\u2028\u2029"]`, "[\"\u2028\u2029\"]", false},
+		{"nonBMPKeys", `{"\uff61":1,"\ud83d\ude00":2,"\u20ac":3,"\r":4,"1":5}`, `{"\r":4,"1":5,"€":3,"😀":2,"｡":1}`, false},
+		{"invalid", `{"a":}`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := canonicalJSON([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("canonicalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("canonicalJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 module github.com/matt0xFF/gltf
 
+go 1.24
+
 require (
 	github.com/go-playground/locales v0.12.1 // indirect
 	github.com/go-playground/universal-translator v0.16.0 // indirect
@@ -0,0 +1,143 @@
+package gltf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// An HTTPLoader reads a glTF or GLB document and its external resources over HTTP or HTTPS.
+// Relative resource URIs are resolved against the URL of the document, after following the redirects.
+// The resources, and their redirects, are only fetched from the origin of the document and from AllowedHosts,
+// so a document cannot make the loader request arbitrary hosts, such as the ones of an internal network.
+type HTTPLoader struct {
+	Client       *http.Client // Client used for the requests, which can define a Timeout. If nil, http.DefaultClient is used.
+	Quotas       ReadQuotas   // Quotas of the decoder. If zero, the default quotas of NewDecoder are used.
+	AllowedHosts []string     // Other hosts the resources can be fetched from. A host without a port matches any port.
+}
+
+// Open will fetch the document at rawURL and return it.
+// The body of the document is limited to MaxJSONSize, or to MaxMemoryAllocation if it is zero,
+// even when the response has no Content-Length.
+// The responses whose Content-Length exceeds that limit, or differs from the byteLength of the buffer,
+// are rejected before reading their body.
+func (l *HTTPLoader) Open(ctx context.Context, rawURL string) (*Document, error) {
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	r, err := l.get(ctx, base, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	base = r.resp.Request.URL
+	cb := func(ctx context.Context, uri string) (io.ReadCloser, error) {
+		ref, err := url.Parse(uri)
+		if err != nil {
+			return nil, err
+		}
+		return l.get(ctx, base.ResolveReference(ref), func(u *url.URL) error {
+			if u.Scheme == base.Scheme && u.Host == base.Host {
+				return nil
+			}
+			for _, host := range l.AllowedHosts {
+				if host == u.Host || host == u.Hostname() {
+					return nil
+				}
+			}
+			return errors.New("gltf: Host " + u.Host + " is not allowed")
+		})
+	}
+	d := NewDecoderContext(r, cb)
+	if l.Quotas != (ReadQuotas{}) {
+		d.SetQuotas(l.Quotas)
+	}
+	r.quota, r.limit = "MaxMemoryAllocation", d.quotas.MaxMemoryAllocation
+	if d.quotas.MaxJSONSize > 0 {
+		r.quota, r.limit = "MaxJSONSize", d.quotas.MaxJSONSize
+	}
+	if r.size() > int64(r.limit) {
+		return nil, &QuotaError{Quota: r.quota, Limit: r.limit, Value: int(r.size())}
+	}
+	doc := new(Document)
+	if err = d.DecodeContext(ctx, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// get fetches u. If allow is not nil, it checks u and the URLs it redirects to.
+func (l *HTTPLoader) get(ctx context.Context, u *url.URL, allow func(*url.URL) error) (*httpResource, error) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.New("gltf: Unsupported URL scheme " + u.Scheme)
+	}
+	if allow != nil {
+		if err := allow(u); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if allow != nil {
+		c := *client
+		checkRedirect := client.CheckRedirect
+		c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if err := allow(req.URL); err != nil {
+				return err
+			}
+			if checkRedirect != nil {
+				return checkRedirect(req, via)
+			}
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return nil
+		}
+		client = &c
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("gltf: GET %s: %s", u, resp.Status)
+	}
+	return &httpResource{resp: resp}, nil
+}
+
+// httpResource is the body of an HTTP response, whose size is its Content-Length.
+// If limit is not zero, reading more than limit bytes fails with a QuotaError.
+type httpResource struct {
+	resp  *http.Response
+	quota string
+	limit int
+	read  int
+}
+
+func (r *httpResource) Read(p []byte) (int, error) {
+	if r.limit > 0 && r.read > r.limit {
+		return 0, &QuotaError{Quota: r.quota, Limit: r.limit, Value: r.read}
+	}
+	if r.limit > 0 && len(p) > r.limit-r.read+1 {
+		p = p[:r.limit-r.read+1]
+	}
+	n, err := r.resp.Body.Read(p)
+	if r.read += n; r.limit > 0 && r.read > r.limit {
+		return n, &QuotaError{Quota: r.quota, Limit: r.limit, Value: r.read}
+	}
+	return n, err
+}
+
+func (r *httpResource) Close() error { return r.resp.Body.Close() }
+func (r *httpResource) size() int64  { return r.resp.ContentLength }
@@ -0,0 +1,156 @@
+package gltf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPLoader_Open(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/testdata/", http.StripPrefix("/testdata/", http.FileServer(http.Dir("testdata"))))
+	mux.Handle("/redirect", http.RedirectHandler("/testdata/Cube/glTF/Cube.gltf", http.StatusFound))
+	mux.HandleFunc("/long/a.gltf", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"asset":{"version":"2.0"},"buffers":[{"byteLength":3,"uri":"a.bin"}]}`))
+	})
+	mux.HandleFunc("/long/a.bin", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("abcd"))
+	})
+	mux.HandleFunc("/missing/a.gltf", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"asset":{"version":"2.0"},"buffers":[{"byteLength":3,"uri":"a%20b.bin"}]}`))
+	})
+	mux.HandleFunc("/file/a.gltf", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"asset":{"version":"2.0"},"buffers":[{"byteLength":3,"uri":"file:///etc/passwd"}]}`))
+	})
+	mux.HandleFunc("/chunked.gltf", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"asset":{"version":"2.0"},"extras":"`))
+		for i := 0; i < 64; i++ {
+			w.Write([]byte(strings.Repeat("a", 1024)))
+			w.(http.Flusher).Flush()
+		}
+		w.Write([]byte(`"}`))
+	})
+	mux.HandleFunc("/slow.gltf", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	want, err := Open("testdata/Cube/glTF/Cube.gltf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		l       *HTTPLoader
+		path    string
+		wantErr func(error) bool
+	}{
+		{"base", new(HTTPLoader), "/testdata/Cube/glTF/Cube.gltf", nil},
+		{"redirect", &HTTPLoader{Client: srv.Client()}, "/redirect", nil},
+		{"notFound", new(HTTPLoader), "/testdata/notFound.gltf", func(err error) bool { return strings.Contains(err.Error(), "404") }},
+		{"missingResource", new(HTTPLoader), "/missing/a.gltf", func(err error) bool {
+			var e *ResourceError
+			return errors.As(err, &e) && strings.Contains(e.Err.Error(), "/missing/a%20b.bin")
+		}},
+		{"contentLength", new(HTTPLoader), "/long/a.gltf", func(err error) bool {
+			var e *ByteLengthError
+			return errors.As(err, &e) && e.Length == 4
+		}},
+		{"quota", &HTTPLoader{Quotas: ReadQuotas{MaxBufferCount: 8, MaxMemoryAllocation: 100}}, "/testdata/Cube/glTF/Cube.gltf", func(err error) bool {
+			var e *QuotaError
+			return errors.As(err, &e) && e.Quota == "MaxMemoryAllocation"
+		}},
+		{"chunked", &HTTPLoader{Quotas: ReadQuotas{MaxBufferCount: 8, MaxMemoryAllocation: 1 << 20, MaxJSONSize: 10000}}, "/chunked.gltf", func(err error) bool {
+			var e *QuotaError
+			return errors.As(err, &e) && e.Quota == "MaxJSONSize" && e.Value <= 10001
+		}},
+		{"chunkedMemory", &HTTPLoader{Quotas: ReadQuotas{MaxBufferCount: 8, MaxMemoryAllocation: 10000}}, "/chunked.gltf", func(err error) bool {
+			var e *QuotaError
+			return errors.As(err, &e) && e.Quota == "MaxMemoryAllocation" && e.Value <= 10001
+		}},
+		{"scheme", new(HTTPLoader), "/file/a.gltf", func(err error) bool { return errors.As(err, new(*ResourceError)) }},
+		{"timeout", &HTTPLoader{Client: &http.Client{Timeout: 20 * time.Millisecond}}, "/slow.gltf", func(err error) bool { return errors.Is(err, context.DeadlineExceeded) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.l.Open(context.Background(), srv.URL+tt.path)
+			if tt.wantErr != nil {
+				if err == nil || !tt.wantErr(err) {
+					t.Errorf("HTTPLoader.Open() error = %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("HTTPLoader.Open() error = %v", err)
+			}
+			if diff := deepEqualDocument(got, want); diff != nil {
+				t.Errorf("HTTPLoader.Open() = %v", diff)
+			}
+		})
+	}
+}
+
+func TestHTTPLoader_Open_hosts(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("abc"))
+	}))
+	defer other.Close()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/other.gltf", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"asset":{"version":"2.0"},"buffers":[{"byteLength":3,"uri":"` + other.URL + `/a.bin"}]}`))
+	})
+	mux.HandleFunc("/redirect.gltf", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"asset":{"version":"2.0"},"buffers":[{"byteLength":3,"uri":"redirect.bin"}]}`))
+	})
+	mux.Handle("/redirect.bin", http.RedirectHandler(other.URL+"/a.bin", http.StatusFound))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	otherHost := strings.TrimPrefix(other.URL, "http://")
+	tests := []struct {
+		name    string
+		l       *HTTPLoader
+		path    string
+		wantErr bool
+	}{
+		{"otherHost", new(HTTPLoader), "/other.gltf", true},
+		{"otherHostRedirect", new(HTTPLoader), "/redirect.gltf", true},
+		{"allowedHost", &HTTPLoader{AllowedHosts: []string{otherHost}}, "/other.gltf", false},
+		{"allowedHostname", &HTTPLoader{AllowedHosts: []string{"127.0.0.1"}}, "/other.gltf", false},
+		{"allowedHostRedirect", &HTTPLoader{AllowedHosts: []string{otherHost}}, "/redirect.gltf", false},
+		{"otherPort", &HTTPLoader{AllowedHosts: []string{"127.0.0.1:1"}}, "/other.gltf", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.l.Open(context.Background(), srv.URL+tt.path)
+			if tt.wantErr {
+				if !errors.As(err, new(*ResourceError)) || !strings.Contains(err.Error(), "not allowed") {
+					t.Errorf("HTTPLoader.Open() error = %v, want not allowed", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("HTTPLoader.Open() error = %v", err)
+			}
+			if string(got.Buffers[0].Data) != "abc" {
+				t.Errorf("HTTPLoader.Open() buffer = %s, want abc", got.Buffers[0].Data)
+			}
+		})
+	}
+}
+
+func TestHTTPLoader_Open_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := new(HTTPLoader).Open(ctx, "http://127.0.0.1:1/a.gltf"); !errors.Is(err, context.Canceled) {
+		t.Errorf("HTTPLoader.Open() error = %v, want context.Canceled", err)
+	}
+}
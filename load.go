@@ -0,0 +1,111 @@
+package gltf
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// maxChunk is the maximum number of bytes read or written between two cancellation checks.
+const maxChunk = 64 * 1024
+
+// loadResources calls load for every index in [start, end).
+// If the decoder parallelism is greater than 1 the calls run concurrently with bounded parallelism.
+// When a call fails the context of the outstanding calls with a greater index is cancelled
+// and no more calls are started, so the returned error is the one of the lowest failing index,
+// exactly as in a sequential load.
+func (d *Decoder) loadResources(ctx context.Context, start, end int, load func(context.Context, int) error) error {
+	if d.parallelism < 2 || end-start < 2 {
+		for i := start; i < end; i++ {
+			if err := load(ctx, i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		failed   = end
+		firstErr error
+		cancels  = make([]context.CancelFunc, end)
+		sem      = make(chan struct{}, d.parallelism)
+	)
+	for i := start; i < end; i++ {
+		sem <- struct{}{}
+		mu.Lock()
+		if i > failed {
+			mu.Unlock()
+			break
+		}
+		ictx, cancel := context.WithCancel(ctx)
+		cancels[i] = cancel
+		mu.Unlock()
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := load(ictx, i)
+			mu.Lock()
+			cancels[i]()
+			if err != nil && i < failed {
+				failed, firstErr = i, err
+				for j := i + 1; j < end; j++ {
+					if cancels[j] != nil {
+						cancels[j]()
+					}
+				}
+			}
+			mu.Unlock()
+			<-sem
+		}(i)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// contextReader reads from r until ctx is done.
+// Reads are split in chunks of at most maxChunk bytes so cancellation is observed promptly.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if len(p) > maxChunk {
+		p = p[:maxChunk]
+	}
+	return r.r.Read(p)
+}
+
+// contextWriter writes to w until ctx is done.
+// Writes are split in chunks of at most maxChunk bytes so cancellation is observed promptly.
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (w *contextWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		if err = w.ctx.Err(); err != nil {
+			return
+		}
+		chunk := p
+		if len(chunk) > maxChunk {
+			chunk = chunk[:maxChunk]
+		}
+		var m int
+		m, err = w.w.Write(chunk)
+		n += m
+		if err != nil {
+			return
+		}
+		if m < len(chunk) {
+			return n, io.ErrShortWrite
+		}
+		p = p[m:]
+	}
+	return
+}
@@ -0,0 +1,108 @@
+package gltf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowReader returns its content one byte at a time, waiting delay before each read.
+type slowReader struct {
+	r     io.Reader
+	delay time.Duration
+	read  *int64
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	time.Sleep(r.delay)
+	n, err := r.r.Read(p[:1])
+	atomic.AddInt64(r.read, int64(n))
+	return n, err
+}
+
+func buffersJSON(n, byteLength int) string {
+	buffers := make([]string, n)
+	for i := range buffers {
+		buffers[i] = fmt.Sprintf(`{"byteLength": %d, "uri": "%d.bin"}`, byteLength, i)
+	}
+	return fmt.Sprintf(`{"buffers": [%s]}`, strings.Join(buffers, ","))
+}
+
+func TestDecoder_SetParallelism(t *testing.T) {
+	var inFlight, maxInFlight int32
+	cb := func(uri string) (io.ReadCloser, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return ioutil.NopCloser(bytes.NewBufferString(uri[:1] + "x")), nil
+	}
+	doc := new(Document)
+	err := NewDecoder(bytes.NewBufferString(buffersJSON(8, 2)), cb).SetParallelism(3).Decode(doc)
+	if err != nil {
+		t.Fatalf("Decoder.Decode() error = %v", err)
+	}
+	for i, b := range doc.Buffers {
+		if want := fmt.Sprintf("%dx", i); string(b.Data) != want {
+			t.Errorf("Decoder.Decode() buffer %d = %s, want %s", i, b.Data, want)
+		}
+	}
+	if maxInFlight > 3 {
+		t.Errorf("Decoder.Decode() loaded %d resources concurrently, want at most 3", maxInFlight)
+	}
+}
+
+func TestDecoder_SetParallelism_firstError(t *testing.T) {
+	errSlow, errFast := errors.New("slow"), errors.New("fast")
+	cb := func(uri string) (io.ReadCloser, error) {
+		switch uri {
+		case "2.bin":
+			time.Sleep(20 * time.Millisecond)
+			return nil, errSlow
+		case "5.bin":
+			return nil, errFast
+		}
+		return ioutil.NopCloser(bytes.NewBufferString("a")), nil
+	}
+	for i := 0; i < 5; i++ {
+		err := NewDecoder(bytes.NewBufferString(buffersJSON(8, 1)), cb).SetParallelism(8).Decode(new(Document))
+		if !errors.Is(err, errSlow) {
+			t.Fatalf("Decoder.Decode() error = %v, want %v", err, errSlow)
+		}
+	}
+}
+
+func TestDecoder_SetParallelism_cancel(t *testing.T) {
+	var read int64
+	errFail := errors.New("fail")
+	cb := func(uri string) (io.ReadCloser, error) {
+		switch uri {
+		case "0.bin":
+			return ioutil.NopCloser(bytes.NewReader(make([]byte, 1000))), nil
+		case "1.bin":
+			time.Sleep(10 * time.Millisecond)
+			return nil, errFail
+		}
+		return ioutil.NopCloser(&slowReader{r: bytes.NewReader(make([]byte, 1000)), delay: time.Millisecond, read: &read}), nil
+	}
+	doc := new(Document)
+	err := NewDecoder(bytes.NewBufferString(buffersJSON(4, 1000)), cb).SetParallelism(4).Decode(doc)
+	if !errors.Is(err, errFail) {
+		t.Fatalf("Decoder.Decode() error = %v, want %v", err, errFail)
+	}
+	// The reads of buffers 2 and 3 are cancelled.
+	if got := atomic.LoadInt64(&read); got >= 2000 {
+		t.Errorf("Decoder.Decode() read %d bytes, outstanding reads were not cancelled", got)
+	}
+}
@@ -0,0 +1,72 @@
+package gltf
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// A MappedDocument is a Document decoded from a memory-mapped file.
+// The data of the GLB BIN buffer, and of the images pointing to it, are slices of the mapping,
+// so they must not be used after calling Close.
+type MappedDocument struct {
+	*Document
+	data []byte
+}
+
+// Close releases the memory mapping.
+func (m *MappedDocument) Close() error {
+	if m.data == nil {
+		return nil
+	}
+	err := munmap(m.data)
+	m.data = nil
+	return err
+}
+
+// OpenMapped is like Open but memory-maps the file specified by name instead of copying it,
+// which halves the peak memory needed to load a big GLB file.
+// The mapping is private, so modifying the buffer data does not modify the file.
+// The BIN chunk and the unknown chunks are not allocated, so they are not limited by the default ReadQuotas,
+// which still apply to the JSON content and to the external resources.
+// On the platforms without memory mapping the file is read into memory.
+func OpenMapped(name string) (*MappedDocument, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := mmapFile(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	m := &MappedDocument{Document: new(Document), data: data}
+	fsys := os.DirFS(filepath.Dir(name))
+	cb := func(uri string) (io.ReadCloser, error) {
+		p, err := resolveURI(uri)
+		if err != nil {
+			return nil, err
+		}
+		return fsys.Open(p)
+	}
+	mr := &mappedReader{Reader: bytes.NewReader(data), data: data}
+	d := NewDecoder(mr, cb)
+	d.mapped = mr
+	if err = d.Decode(m.Document); err != nil {
+		m.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// mappedReader reads a memory-mapped file and lets the decoder slice the mapping instead of copying it.
+type mappedReader struct {
+	*bytes.Reader
+	data []byte
+}
+
+// offset returns the position of the next byte read by the decoder in the mapping.
+func (m *mappedReader) offset(buffered int) int {
+	return len(m.data) - m.Len() - buffered
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package gltf
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+func mmapFile(f *os.File) ([]byte, error) {
+	return ioutil.ReadAll(f)
+}
+
+func munmap(data []byte) error {
+	return nil
+}
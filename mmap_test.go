@@ -0,0 +1,102 @@
+package gltf
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+)
+
+func TestOpenMapped(t *testing.T) {
+	tests := []struct {
+		name     string
+		isBinary bool
+	}{
+		{"testdata/BoxVertexColors/glTF-Binary/BoxVertexColors.glb", true},
+		{"testdata/OrientationTest/glTF-Binary/OrientationTest.glb", true},
+		{"testdata/Cube/glTF/Cube.gltf", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := Open(tt.name)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			got, err := OpenMapped(tt.name)
+			if err != nil {
+				t.Fatalf("OpenMapped() error = %v", err)
+			}
+			defer got.Close()
+			if diff := deepEqualDocument(got.Document, want); diff != nil {
+				t.Errorf("OpenMapped() = %v", diff)
+			}
+			if tt.isBinary {
+				start := uintptr(unsafe.Pointer(&got.data[0]))
+				p := uintptr(unsafe.Pointer(&got.Buffers[0].Data[0]))
+				if p < start || p >= start+uintptr(len(got.data)) {
+					t.Errorf("OpenMapped() buffer data is not a slice of the mapping")
+				}
+			}
+			if err := got.Close(); err != nil {
+				t.Errorf("MappedDocument.Close() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestOpenMapped_errors(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/BoxVertexColors/glTF-Binary/BoxVertexColors.glb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "gltf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	truncated := filepath.Join(dir, "truncated.glb")
+	if err := ioutil.WriteFile(truncated, data[:len(data)-10], 0644); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(dir, "empty.glb")
+	if err := ioutil.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenMapped(truncated); !errors.As(err, new(*ByteLengthError)) {
+		t.Errorf("OpenMapped() error = %v, want *ByteLengthError", err)
+	}
+	if _, err := OpenMapped(empty); err == nil {
+		t.Errorf("OpenMapped() expected error for an empty file")
+	}
+	if _, err := OpenMapped(filepath.Join(dir, "notFound.glb")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("OpenMapped() error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestOpenMapped_overDefaultQuota(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gltf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "big.glb")
+	size := 33 * 1024 * 1024
+	doc := &Document{Buffers: []Buffer{{ByteLength: uint32(size), Data: make([]uint8, size)}}}
+	doc.Buffers[0].Data[size-1] = 1
+	if err := Save(doc, name, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(name); !errors.As(err, new(*QuotaError)) {
+		t.Fatalf("Open() error = %v, want *QuotaError", err)
+	}
+	got, err := OpenMapped(name)
+	if err != nil {
+		t.Fatalf("OpenMapped() error = %v", err)
+	}
+	defer got.Close()
+	if data := got.Buffers[0].Data; len(data) != size || data[size-1] != 1 {
+		t.Errorf("OpenMapped() buffer data has %d bytes, want %d", len(data), size)
+	}
+}
@@ -0,0 +1,150 @@
+package gltf
+
+import (
+	"context"
+)
+
+// Pack lays out the buffer views of doc into their buffers and updates all the offsets and lengths.
+// The content of each view is its Data, if set, or the bytes of the buffer it points to,
+// so documents built by hand only have to fill in the views and the bytes that are not
+// part of any view are dropped. Each view starts at a multiple of 4 bytes, so the accessors
+// are aligned to their component size as long as their byteOffset and the byteStride of their views are.
+// The buffers that are left without views are removed and the embedded ones get a new data URI.
+//
+// A *ValidationError is returned when an accessor cannot be aligned,
+// such as a float accessor with a byteOffset of 2 or a vertex attribute with a byteStride of 6.
+func Pack(doc *Document) error {
+	return PackContext(context.Background(), doc)
+}
+
+// PackContext is like Pack but aborts loading the buffers as soon as ctx is done.
+func PackContext(ctx context.Context, doc *Document) error {
+	if err := checkAlignment(doc); err != nil {
+		return err
+	}
+	contents := make([][]uint8, len(doc.BufferViews))
+	maxBuffer := int32(len(doc.Buffers)) - 1
+	for i := range doc.BufferViews {
+		view := &doc.BufferViews[i]
+		if view.Buffer < 0 {
+			continue
+		}
+		if view.Buffer > maxBuffer {
+			maxBuffer = view.Buffer
+		}
+		if view.Data != nil {
+			contents[i] = view.Data
+			continue
+		}
+		data, err := bufferViewData(ctx, doc, i)
+		if err != nil {
+			return err
+		}
+		contents[i] = data
+	}
+	if n := int(maxBuffer) + 1; n > len(doc.Buffers) {
+		doc.Buffers = append(doc.Buffers, make([]Buffer, n-len(doc.Buffers))...)
+	}
+
+	packed := make([][]uint8, len(doc.Buffers))
+	used := make([]bool, len(doc.Buffers))
+	for i := range doc.BufferViews {
+		view := &doc.BufferViews[i]
+		if view.Buffer < 0 {
+			continue
+		}
+		used[view.Buffer] = true
+		var offset uint32
+		packed[view.Buffer], offset = appendAligned(packed[view.Buffer], contents[i])
+		view.ByteOffset, view.ByteLength, view.Data = offset, uint32(len(contents[i])), nil
+	}
+
+	indices := make([]int32, len(doc.Buffers))
+	buffers := doc.Buffers[:0]
+	for i, buffer := range doc.Buffers {
+		indices[i] = int32(len(buffers))
+		if !used[i] {
+			continue
+		}
+		buffer.Data, buffer.ByteLength = packed[i], uint32(len(packed[i]))
+		if buffer.IsEmbeddedResource() {
+			// The encoder writes the data URI, not the data.
+			buffer.EmbeddedResource()
+		}
+		buffers = append(buffers, buffer)
+	}
+	doc.Buffers = buffers
+	for i := range doc.BufferViews {
+		if view := &doc.BufferViews[i]; view.Buffer >= 0 {
+			view.Buffer = indices[view.Buffer]
+		}
+	}
+	return nil
+}
+
+// checkAlignment checks that the accessors data can be aligned to its component size
+// and, for vertex attributes, to 4 bytes, once its view is aligned to 4 bytes.
+// The vertex attributes are the accessors of the primitive attributes and morph targets
+// and the ones whose view targets ArrayBuffer.
+func checkAlignment(doc *Document) error {
+	attributes := make(map[uint32]bool)
+	for _, mesh := range doc.Meshes {
+		for _, primitive := range mesh.Primitives {
+			for _, accessor := range primitive.Attributes {
+				attributes[accessor] = true
+			}
+			for _, target := range primitive.Targets {
+				for _, accessor := range target {
+					attributes[accessor] = true
+				}
+			}
+		}
+	}
+	check := func(path string, view int, offset uint32, size uint32, attribute bool) error {
+		if view < 0 || view >= len(doc.BufferViews) {
+			return &ValidationError{Path: path + ".bufferView", Tag: "lt", Value: view}
+		}
+		v := &doc.BufferViews[view]
+		if (attribute || v.Target == ArrayBuffer) && size < 4 {
+			size = 4
+		}
+		if offset%size != 0 {
+			return &ValidationError{Path: path + ".byteOffset", Tag: "multiple", Value: offset}
+		}
+		if v.ByteStride%size != 0 {
+			return &ValidationError{Path: indexPath("bufferViews", view) + ".byteStride", Tag: "multiple", Value: v.ByteStride}
+		}
+		return nil
+	}
+	for i, accessor := range doc.Accessors {
+		path := indexPath("accessors", i)
+		if accessor.BufferView >= 0 {
+			if err := check(path, int(accessor.BufferView), accessor.ByteOffset, componentSize(accessor.ComponentType), attributes[uint32(i)]); err != nil {
+				return err
+			}
+		}
+		if accessor.Sparse == nil {
+			continue
+		}
+		indices, values := &accessor.Sparse.Indices, &accessor.Sparse.Values
+		if err := check(path+".sparse.indices", int(indices.BufferView), indices.ByteOffset, componentSize(indices.ComponentType), false); err != nil {
+			return err
+		}
+		if err := check(path+".sparse.values", int(values.BufferView), values.ByteOffset, componentSize(accessor.ComponentType), false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// componentSize returns the size in bytes of a component.
+func componentSize(c ComponentType) uint32 {
+	switch c {
+	case UnsignedShort, Short:
+		return 2
+	case UnsignedInt, Float:
+		return 4
+	default:
+		return 1
+	}
+}
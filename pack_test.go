@@ -0,0 +1,161 @@
+package gltf
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestPack(t *testing.T) {
+	doc := &Document{
+		Accessors: []Accessor{
+			{BufferView: 0, ComponentType: UnsignedByte, Count: 3, Type: Scalar},
+			{BufferView: 1, ComponentType: Float, Count: 1, Type: Vec2},
+			{BufferView: 2, ComponentType: UnsignedShort, Count: 1, Type: Scalar, ByteOffset: 2},
+		},
+		Buffers: []Buffer{
+			{ByteLength: 100},
+			{ByteLength: 9, URI: "a.bin", Data: []uint8{0, 0, 0, 0, 0, 7, 8, 9, 0}},
+			{ByteLength: 1, URI: "unused.bin", Data: []uint8{1}},
+		},
+		BufferViews: []BufferView{
+			{Buffer: 0, Data: []uint8{1, 2, 3}, Target: ElementArrayBuffer},
+			{Buffer: 0, Data: []uint8{1, 2, 3, 4, 5, 6, 7, 8}, Target: ArrayBuffer, ByteStride: 8},
+			{Buffer: 1, ByteOffset: 3, ByteLength: 4},
+			{Buffer: 3, Data: []uint8{4, 5}},
+			{Buffer: -1},
+		},
+	}
+	if err := Pack(doc); err != nil {
+		t.Fatalf("Pack() error = %v", err)
+	}
+	wantViews := []struct {
+		buffer int32
+		offset uint32
+		data   []uint8
+	}{{0, 0, []uint8{1, 2, 3}}, {0, 4, []uint8{1, 2, 3, 4, 5, 6, 7, 8}}, {1, 0, []uint8{0, 0, 7, 8}}, {2, 0, []uint8{4, 5}}, {-1, 0, nil}}
+	for i, want := range wantViews {
+		view := doc.BufferViews[i]
+		if view.Buffer != want.buffer || view.ByteOffset != want.offset || view.ByteLength != uint32(len(want.data)) || view.Data != nil {
+			t.Errorf("Pack() bufferViews[%d] = %v, want buffer %d offset %d length %d", i, view, want.buffer, want.offset, len(want.data))
+			continue
+		}
+		if view.Buffer >= 0 {
+			if data := doc.Buffers[view.Buffer].Data[view.ByteOffset : view.ByteOffset+view.ByteLength]; !bytes.Equal(data, want.data) {
+				t.Errorf("Pack() bufferViews[%d] data = %v, want %v", i, data, want.data)
+			}
+		}
+	}
+	wantBuffers := []struct {
+		uri    string
+		length uint32
+	}{{"", 12}, {"a.bin", 4}, {"", 2}}
+	if len(doc.Buffers) != len(wantBuffers) {
+		t.Fatalf("Pack() buffers = %v", doc.Buffers)
+	}
+	for i, want := range wantBuffers {
+		if b := doc.Buffers[i]; b.URI != want.uri || b.ByteLength != want.length || len(b.Data) != int(want.length) {
+			t.Errorf("Pack() buffers[%d] = %s %d, want %s %d", i, b.URI, b.ByteLength, want.uri, want.length)
+		}
+	}
+
+	got, _ := convertMemory(t, doc, LayoutBinary, nil)
+	if view := got.BufferViews[3]; !bytes.Equal(got.Buffers[0].Data[view.ByteOffset:view.ByteOffset+view.ByteLength], []uint8{4, 5}) {
+		t.Errorf("Pack() bufferViews[3] = %v", view)
+	}
+}
+
+func TestPack_alignment(t *testing.T) {
+	tests := []struct {
+		name     string
+		accessor Accessor
+		view     BufferView
+		path     string
+	}{
+		{"offset", Accessor{ComponentType: Float, ByteOffset: 2}, BufferView{ByteLength: 8}, "accessors[0].byteOffset"},
+		{"stride", Accessor{ComponentType: UnsignedShort}, BufferView{ByteLength: 8, ByteStride: 5}, "bufferViews[0].byteStride"},
+		{"vertexOffset", Accessor{ComponentType: UnsignedByte, ByteOffset: 1}, BufferView{ByteLength: 8, Target: ArrayBuffer}, "accessors[0].byteOffset"},
+		{"vertexStride", Accessor{ComponentType: UnsignedShort}, BufferView{ByteLength: 8, ByteStride: 6, Target: ArrayBuffer}, "bufferViews[0].byteStride"},
+		{"bufferView", Accessor{BufferView: 1}, BufferView{ByteLength: 8}, "accessors[0].bufferView"},
+		{"sparseIndices", Accessor{BufferView: -1, Sparse: &Sparse{Indices: SparseIndices{ComponentType: UnsignedInt, ByteOffset: 2}}}, BufferView{ByteLength: 8}, "accessors[0].sparse.indices.byteOffset"},
+		{"sparseValues", Accessor{BufferView: -1, ComponentType: Short, Sparse: &Sparse{Indices: SparseIndices{ComponentType: UnsignedByte}, Values: SparseValues{ByteOffset: 3}}}, BufferView{ByteLength: 8}, "accessors[0].sparse.values.byteOffset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &Document{
+				Accessors:   []Accessor{tt.accessor},
+				Buffers:     []Buffer{{ByteLength: 8, Data: make([]uint8, 8)}},
+				BufferViews: []BufferView{tt.view},
+			}
+			var e *ValidationError
+			if err := Pack(doc); !errors.As(err, &e) || e.Path != tt.path {
+				t.Errorf("Pack() error = %v, want path %s", err, tt.path)
+			}
+		})
+	}
+}
+
+func TestPack_attributeAlignment(t *testing.T) {
+	// The views of the primitive attributes are vertex buffers even without a target.
+	for _, mesh := range []Mesh{
+		{Primitives: []Primitive{{Attributes: Attribute{"COLOR_0": 0}}}},
+		{Primitives: []Primitive{{Attributes: Attribute{"POSITION": 1}, Targets: []Attribute{{"POSITION": 0}}}}},
+	} {
+		doc := &Document{
+			Accessors:   []Accessor{{ComponentType: UnsignedShort, ByteOffset: 2}, {BufferView: -1, ComponentType: Float}},
+			Buffers:     []Buffer{{ByteLength: 8, Data: make([]uint8, 8)}},
+			BufferViews: []BufferView{{ByteLength: 8}},
+			Meshes:      []Mesh{mesh},
+		}
+		var e *ValidationError
+		if err := Pack(doc); !errors.As(err, &e) || e.Path != "accessors[0].byteOffset" {
+			t.Errorf("Pack() error = %v, want path accessors[0].byteOffset", err)
+		}
+	}
+}
+
+func TestPack_embedded(t *testing.T) {
+	doc, err := Open("testdata/Triangle/glTF-Embedded/Triangle.gltf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.BufferViews[0].Data = []uint8{9, 9, 9, 9, 9, 9}
+	if err := Pack(doc); err != nil {
+		t.Fatalf("Pack() error = %v", err)
+	}
+	buf := new(bytes.Buffer)
+	if err := NewEncoder(buf, nil, false).Encode(doc); err != nil {
+		t.Fatalf("Encoder.Encode() error = %v", err)
+	}
+	got := new(Document)
+	if err := NewDecoder(buf, nil).Decode(got); err != nil {
+		t.Fatalf("Decoder.Decode() error = %v", err)
+	}
+	view := got.BufferViews[0]
+	if data := got.Buffers[view.Buffer].Data[view.ByteOffset : view.ByteOffset+view.ByteLength]; !bytes.Equal(data, []uint8{9, 9, 9, 9, 9, 9}) {
+		t.Errorf("Pack() bufferViews[0] = %v, want the packed data in the data URI", data)
+	}
+}
+
+func TestPack_viewErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		view BufferView
+		path string
+	}{
+		{"buffer", BufferView{Buffer: 1, ByteLength: 4}, "bufferViews[0].buffer"},
+		{"byteLength", BufferView{ByteOffset: 6, ByteLength: 4}, "bufferViews[0].byteLength"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &Document{
+				Buffers:     []Buffer{{ByteLength: 8, Data: make([]uint8, 8)}},
+				BufferViews: []BufferView{tt.view},
+			}
+			var e *ValidationError
+			if err := Pack(doc); !errors.As(err, &e) || e.Path != tt.path {
+				t.Errorf("Pack() error = %v, want path %s", err, tt.path)
+			}
+		})
+	}
+}
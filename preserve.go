@@ -0,0 +1,320 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// A jsonNode is a parsed JSON value that keeps its original text and the order of its keys.
+type jsonNode struct {
+	raw   []byte     // Original text of the value.
+	keys  []string   // Keys of an object, in order.
+	elems []jsonNode // Members of an object, in the same order as keys, or elements of an array.
+	kind  byte       // '{', '[' or 0 for the other values.
+}
+
+func (n *jsonNode) member(key string) *jsonNode {
+	if n == nil || n.kind != '{' {
+		return nil
+	}
+	for i, k := range n.keys {
+		if k == key {
+			return &n.elems[i]
+		}
+	}
+	return nil
+}
+
+func (n *jsonNode) elem(i int) *jsonNode {
+	if n == nil || n.kind != '[' || i >= len(n.elems) {
+		return nil
+	}
+	return &n.elems[i]
+}
+
+var errInvalidJSON = errors.New("gltf: Invalid JSON")
+
+// parseJSON parses the first JSON value of data, which must be valid.
+func parseJSON(data []byte) (*jsonNode, error) {
+	p := jsonParser{data: data}
+	n, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+type jsonParser struct {
+	data []byte
+	pos  int
+}
+
+func (p *jsonParser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *jsonParser) next(c byte) error {
+	p.skipSpace()
+	if p.pos >= len(p.data) || p.data[p.pos] != c {
+		return errInvalidJSON
+	}
+	p.pos++
+	return nil
+}
+
+func (p *jsonParser) value() (jsonNode, error) {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return jsonNode{}, errInvalidJSON
+	}
+	start := p.pos
+	var n jsonNode
+	var err error
+	switch c := p.data[p.pos]; c {
+	case '{':
+		n.kind = c
+		err = p.object(&n)
+	case '[':
+		n.kind = c
+		err = p.array(&n)
+	case '"':
+		err = p.string()
+	default:
+		for p.pos < len(p.data) && bytes.IndexByte([]byte(",:]} \t\n\r"), p.data[p.pos]) < 0 {
+			p.pos++
+		}
+	}
+	n.raw = p.data[start:p.pos]
+	return n, err
+}
+
+func (p *jsonParser) object(n *jsonNode) error {
+	p.pos++
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == '}' {
+		p.pos++
+		return nil
+	}
+	for {
+		p.skipSpace()
+		start := p.pos
+		if err := p.string(); err != nil {
+			return err
+		}
+		var key string
+		if err := json.Unmarshal(p.data[start:p.pos], &key); err != nil {
+			return err
+		}
+		if err := p.next(':'); err != nil {
+			return err
+		}
+		v, err := p.value()
+		if err != nil {
+			return err
+		}
+		n.keys, n.elems = append(n.keys, key), append(n.elems, v)
+		if err = p.next(','); err != nil {
+			return p.next('}')
+		}
+	}
+}
+
+func (p *jsonParser) array(n *jsonNode) error {
+	p.pos++
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == ']' {
+		p.pos++
+		return nil
+	}
+	for {
+		v, err := p.value()
+		if err != nil {
+			return err
+		}
+		n.elems = append(n.elems, v)
+		if err = p.next(','); err != nil {
+			return p.next(']')
+		}
+	}
+}
+
+func (p *jsonParser) string() error {
+	if p.pos >= len(p.data) || p.data[p.pos] != '"' {
+		return errInvalidJSON
+	}
+	for p.pos++; p.pos < len(p.data); p.pos++ {
+		switch p.data[p.pos] {
+		case '\\':
+			p.pos++
+		case '"':
+			p.pos++
+			return nil
+		}
+	}
+	return errInvalidJSON
+}
+
+// preserveJSON merges the current encoding of a document with the original JSON content it was decoded from.
+func preserveJSON(original, current []byte) ([]byte, error) {
+	var doc Document
+	if err := json.Unmarshal(original, &doc); err != nil {
+		return nil, err
+	}
+	base, err := json.Marshal(&doc)
+	if err != nil {
+		return nil, err
+	}
+	return mergeJSON(original, base, current)
+}
+
+// mergeJSON writes the JSON content of a document decoded from original and encoded as current,
+// keeping the original text of everything that has not been modified.
+// The base is the encoding of the document as decoded from original,
+// which tells apart the properties that were modified or removed
+// from the ones that are not modelled, which are kept.
+func mergeJSON(original, base, current []byte) ([]byte, error) {
+	o, err := parseJSON(original)
+	if err != nil {
+		return nil, err
+	}
+	b, err := parseJSON(base)
+	if err != nil {
+		return nil, err
+	}
+	c, err := parseJSON(current)
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, len(current)))
+	if err = mergeNode(buf, o, b, c); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func mergeNode(buf *bytes.Buffer, o, b, c *jsonNode) error {
+	switch {
+	case b != nil && bytes.Equal(b.raw, c.raw):
+		return json.Compact(buf, o.raw)
+	case o.kind == '{' && c.kind == '{':
+		buf.WriteByte('{')
+		n := 0
+		for i, key := range o.keys {
+			cv, bv := c.member(key), b.member(key)
+			if cv == nil && bv != nil {
+				// Removed or set to its default value.
+				continue
+			}
+			if n > 0 {
+				buf.WriteByte(',')
+			}
+			n++
+			writeKey(buf, key)
+			if cv == nil {
+				// Not modelled or omitted both before and after.
+				if err := json.Compact(buf, o.elems[i].raw); err != nil {
+					return err
+				}
+			} else if err := mergeNode(buf, &o.elems[i], bv, cv); err != nil {
+				return err
+			}
+		}
+		for i, key := range c.keys {
+			if o.member(key) != nil {
+				continue
+			}
+			if n > 0 {
+				buf.WriteByte(',')
+			}
+			n++
+			writeKey(buf, key)
+			buf.Write(c.elems[i].raw)
+		}
+		buf.WriteByte('}')
+	case o.kind == '[' && c.kind == '[':
+		buf.WriteByte('[')
+		match, positional := matchElems(o, b, c)
+		for i := range c.elems {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			switch {
+			case match[i] >= 0:
+				if err := json.Compact(buf, o.elems[match[i]].raw); err != nil {
+					return err
+				}
+			case positional[i]:
+				if err := mergeNode(buf, &o.elems[i], b.elem(i), &c.elems[i]); err != nil {
+					return err
+				}
+			default:
+				buf.Write(c.elems[i].raw)
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		buf.Write(c.raw)
+	}
+	return nil
+}
+
+// matchElems pairs the elements of the current array c with the original ones of o.
+// match[i] is the index of the original element of c.elems[i] when it is unchanged, wherever it moved, or -1.
+// positional[i] tells whether the modified c.elems[i] is merged with the original element at the same index,
+// which is only the case when the length of the array and the position of its unchanged elements are kept,
+// as otherwise the properties that are not modelled could be moved to another element.
+func matchElems(o, b, c *jsonNode) (match []int, positional []bool) {
+	match = make([]int, len(c.elems))
+	used := make([]bool, len(o.elems))
+	for i := range c.elems {
+		match[i] = -1
+		if bv := b.elem(i); bv != nil && i < len(o.elems) && bytes.Equal(bv.raw, c.elems[i].raw) {
+			match[i], used[i] = i, true
+		}
+	}
+	inPlace := len(o.elems) == len(c.elems)
+	for i := range c.elems {
+		if match[i] >= 0 {
+			continue
+		}
+		for j := range o.elems {
+			if bv := b.elem(j); !used[j] && bv != nil && bytes.Equal(bv.raw, c.elems[i].raw) {
+				match[i], used[j], inPlace = j, true, false
+				break
+			}
+		}
+	}
+	positional = make([]bool, len(c.elems))
+	for i := range c.elems {
+		positional[i] = inPlace && match[i] < 0 && !used[i]
+	}
+	return match, positional
+}
+
+func writeKey(buf *bytes.Buffer, key string) {
+	writeCanonicalString(buf, key)
+	buf.WriteByte(':')
+}
+
+// detectIndent returns the indentation of the first nested line of the JSON content data,
+// or an empty string if it is compact.
+func detectIndent(data []byte) string {
+	i := bytes.IndexByte(data, '\n')
+	if i < 0 {
+		return ""
+	}
+	line := data[i+1:]
+	n := 0
+	for n < len(line) && (line[n] == ' ' || line[n] == '\t') {
+		n++
+	}
+	return string(line[:n])
+}
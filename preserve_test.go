@@ -0,0 +1,131 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_mergeJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		base     string
+		current  string
+		want     string
+	}{
+		{"unchanged", `{"b": 1.50, "a": [1E2]}`, `{"a":[100],"b":1.5}`, `{"a":[100],"b":1.5}`, `{"b":1.50,"a":[1E2]}`},
+		{"unknown", `{"x":{"y":1},"a":1}`, `{"a":1}`, `{"a":2}`, `{"x":{"y":1},"a":2}`},
+		{"removed", `{"a":1,"b":[1,1,1]}`, `{"a":1,"b":[1,1,1]}`, `{"a":1}`, `{"a":1}`},
+		{"default", `{"a":1,"scale":[1,1,1]}`, `{"a":1}`, `{"a":2}`, `{"a":2,"scale":[1,1,1]}`},
+		{"added", `{"b":1}`, `{"b":1}`, `{"a":"x","b":1}`, `{"b":1,"a":"x"}`},
+		{"nested", `{"n":[{"u":true,"name":"a","v":0.10},{"name":"b"}]}`, `{"n":[{"name":"a","v":0.1},{"name":"b"}]}`, `{"n":[{"name":"c","v":0.1},{"name":"b"}]}`, `{"n":[{"u":true,"name":"c","v":0.10},{"name":"b"}]}`},
+		{"appended", `{"n":[{"name":"a","x":1},{"name":"b","y":2}]}`, `{"n":[{"name":"a"},{"name":"b"}]}`, `{"n":[{"name":"c"},{"name":"b"},{"name":"d"}]}`, `{"n":[{"name":"c"},{"name":"b","y":2},{"name":"d"}]}`},
+		{"deletedMiddle", `{"n":[{"name":"a","x":1},{"name":"b","y":2},{"name":"c","z":3}]}`, `{"n":[{"name":"a"},{"name":"b"},{"name":"c"}]}`, `{"n":[{"name":"a"},{"name":"c"}]}`, `{"n":[{"name":"a","x":1},{"name":"c","z":3}]}`},
+		{"deletedModified", `{"n":[{"name":"a","x":1},{"name":"b","y":2},{"name":"c","z":3}]}`, `{"n":[{"name":"a"},{"name":"b"},{"name":"c"}]}`, `{"n":[{"name":"a"},{"name":"d"}]}`, `{"n":[{"name":"a","x":1},{"name":"d"}]}`},
+		{"insertedMiddle", `{"n":[{"name":"a","x":1},{"name":"b","y":2}]}`, `{"n":[{"name":"a"},{"name":"b"}]}`, `{"n":[{"name":"a"},{"name":"c"},{"name":"b"}]}`, `{"n":[{"name":"a","x":1},{"name":"c"},{"name":"b","y":2}]}`},
+		{"reordered", `{"n":[{"name":"a","x":1},{"name":"b","y":2},{"name":"c","z":3}]}`, `{"n":[{"name":"a"},{"name":"b"},{"name":"c"}]}`, `{"n":[{"name":"d"},{"name":"a"},{"name":"b"}]}`, `{"n":[{"name":"d"},{"name":"a","x":1},{"name":"b","y":2}]}`},
+		{"truncated", `{"n":[1,2,3]}`, `{"n":[1,2,3]}`, `{"n":[1,5]}`, `{"n":[1,5]}`},
+		{"type", `{"n":[1]}`, `{"n":[1]}`, `{"n":{"a":1}}`, `{"n":{"a":1}}`},
+		{"escaped", `{"a\n":"é", "b":1}`, `{"a\n":"é","b":1}`, `{"a\n":"é","b":2}`, `{"a\n":"é","b":2}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergeJSON([]byte(tt.original), []byte(tt.base), []byte(tt.current))
+			if err != nil {
+				t.Fatalf("mergeJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("mergeJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+	if _, err := mergeJSON([]byte(`{"a":`), []byte(`{}`), []byte(`{}`)); err == nil {
+		t.Error("mergeJSON() expected error")
+	}
+}
+
+func TestDecoder_SetPreserve(t *testing.T) {
+	data := `{"x-tool":{"id":12345678901234567890},"scene":0,"asset":{"version":"2.0","generator":"x"},` +
+		`"nodes":[{"name":"a","scale":[1,1,1],"extras":{"big":9007199254740993,"f":1.50},"unknown":[1,2]},{"mesh":-1,"name":"b"}]}`
+	doc := new(Document)
+	if err := NewDecoder(bytes.NewBufferString(data), nil).SetPreserve(true).Decode(doc); err != nil {
+		t.Fatalf("Decoder.Decode() error = %v", err)
+	}
+	doc.Nodes[1].Name = "c"
+	buf := new(bytes.Buffer)
+	if err := NewEncoder(buf, nil, false).Encode(doc); err != nil {
+		t.Fatalf("Encoder.Encode() error = %v", err)
+	}
+	want := `{"x-tool":{"id":12345678901234567890},"scene":0,"asset":{"version":"2.0","generator":"x"},` +
+		`"nodes":[{"name":"a","scale":[1,1,1],"extras":{"big":9007199254740993,"f":1.50},"unknown":[1,2]},{"mesh":-1,"name":"c"}]}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Encoder.Encode() = %s, want %s", got, want)
+	}
+
+	// The elements are not merged by index once some are deleted.
+	data = `{"asset":{"version":"2.0"},"nodes":[{"name":"a","vendorA":1},{"name":"b","vendorB":2},{"name":"c","vendorC":3}]}`
+	doc = new(Document)
+	if err := NewDecoder(bytes.NewBufferString(data), nil).SetPreserve(true).Decode(doc); err != nil {
+		t.Fatalf("Decoder.Decode() error = %v", err)
+	}
+	doc.Nodes = append(doc.Nodes[:1], doc.Nodes[2:]...)
+	buf.Reset()
+	if err := NewEncoder(buf, nil, false).Encode(doc); err != nil {
+		t.Fatalf("Encoder.Encode() error = %v", err)
+	}
+	want = `{"asset":{"version":"2.0"},"nodes":[{"name":"a","vendorA":1},{"name":"c","vendorC":3}]}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Encoder.Encode() = %s, want %s", got, want)
+	}
+
+	buf.Reset()
+	if err := NewEncoder(buf, nil, false).Encode(&Document{Asset: doc.Asset, Nodes: doc.Nodes}); err != nil {
+		t.Fatalf("Encoder.Encode() error = %v", err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("x-tool")) {
+		t.Errorf("Encoder.Encode() = %s, want the unknown properties dropped", buf)
+	}
+}
+
+func TestDecoder_SetPreserve_testdata(t *testing.T) {
+	files, _ := filepath.Glob("testdata/*/*/*.gltf")
+	if len(files) == 0 {
+		t.Fatal("no testdata files")
+	}
+	for _, name := range files {
+		t.Run(name, func(t *testing.T) {
+			data, err := ioutil.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rcb := func(uri string) (io.ReadCloser, error) {
+				return os.Open(filepath.Join(filepath.Dir(name), filepath.FromSlash(uri)))
+			}
+			doc := new(Document)
+			if err := NewDecoder(bytes.NewReader(data), rcb).SetPreserve(true).Decode(doc); err != nil {
+				t.Fatalf("Decoder.Decode() error = %v", err)
+			}
+			wcb := func(string, int) (io.WriteCloser, error) {
+				return &writeCloser{ioutil.Discard}, nil
+			}
+			buf := new(bytes.Buffer)
+			if err := NewEncoder(buf, wcb, false).Encode(doc); err != nil {
+				t.Fatalf("Encoder.Encode() error = %v", err)
+			}
+			want, got := new(bytes.Buffer), new(bytes.Buffer)
+			json.Compact(want, data)
+			json.Compact(got, buf.Bytes())
+			if !bytes.Equal(got.Bytes(), want.Bytes()) {
+				t.Errorf("Encoder.Encode() = %s, want %s", got, want)
+			}
+			if indent := detectIndent(data); indent != "" && !bytes.Contains(buf.Bytes(), []byte("\n"+indent+"\"")) {
+				t.Errorf("Encoder.Encode() is not indented with %q", indent)
+			}
+		})
+	}
+}
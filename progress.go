@@ -0,0 +1,119 @@
+package gltf
+
+import (
+	"io"
+	"time"
+)
+
+// A Phase is a step of the decoding or the encoding reported to a ProgressFunc.
+type Phase int
+
+const (
+	// PhaseJSON corresponds to the JSON content.
+	PhaseJSON Phase = iota
+	// PhaseBuffer corresponds to the data of a buffer, including the GLB BIN chunk.
+	PhaseBuffer
+	// PhaseImage corresponds to the data of an image.
+	PhaseImage
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseJSON:
+		return "json"
+	case PhaseBuffer:
+		return "buffer"
+	case PhaseImage:
+		return "image"
+	}
+	return "unknown"
+}
+
+// Progress describes how far a phase of the decoding or the encoding is.
+// The first report of each phase has Done equal to zero and the last one has Done equal to Total.
+type Progress struct {
+	Phase   Phase
+	Index   int           // Index of the buffer or image.
+	URI     string        // URI of the buffer or image. It is empty for the JSON content and the GLB BIN chunk.
+	Done    int64         // Bytes read or written so far.
+	Total   int64         // Total bytes of the phase, -1 if it is not known yet.
+	Elapsed time.Duration // Time since the start of the phase.
+}
+
+// ProgressFunc defines a callback that will be called as the decoding or the encoding progresses.
+// When resources are loaded concurrently it is called from several goroutines.
+type ProgressFunc = func(Progress)
+
+// progress reports the progress of a single phase. A nil progress reports nothing.
+type progress struct {
+	fn    ProgressFunc
+	p     Progress
+	start time.Time
+}
+
+func newProgress(fn ProgressFunc, phase Phase, index int, uri string, total int64) *progress {
+	if fn == nil {
+		return nil
+	}
+	pr := &progress{fn: fn, p: Progress{Phase: phase, Index: index, URI: uri, Total: total}, start: time.Now()}
+	pr.report()
+	return pr
+}
+
+func (pr *progress) report() {
+	pr.p.Elapsed = time.Since(pr.start)
+	pr.fn(pr.p)
+}
+
+func (pr *progress) add(n int) {
+	if pr == nil || n == 0 {
+		return
+	}
+	pr.p.Done += int64(n)
+	pr.report()
+}
+
+// finish reports the end of the phase if the last report did not already.
+func (pr *progress) finish() {
+	if pr == nil || pr.p.Done == pr.p.Total {
+		return
+	}
+	pr.p.Total = pr.p.Done
+	pr.report()
+}
+
+func (pr *progress) reader(r io.Reader) io.Reader {
+	if pr == nil {
+		return r
+	}
+	return &progressReader{r: r, pr: pr}
+}
+
+func (pr *progress) writer(w io.Writer) io.Writer {
+	if pr == nil {
+		return w
+	}
+	return &progressWriter{w: w, pr: pr}
+}
+
+type progressReader struct {
+	r  io.Reader
+	pr *progress
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.pr.add(n)
+	return n, err
+}
+
+type progressWriter struct {
+	w  io.Writer
+	pr *progress
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.pr.add(n)
+	return n, err
+}
@@ -0,0 +1,120 @@
+package gltf
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+	"testing"
+)
+
+type progressLog struct {
+	mu      sync.Mutex
+	reports []Progress
+}
+
+func (l *progressLog) add(p Progress) {
+	l.mu.Lock()
+	l.reports = append(l.reports, p)
+	l.mu.Unlock()
+}
+
+// check verifies that every phase starts at zero, grows monotonically and ends with Done equal to Total,
+// and returns the last report of each phase.
+func (l *progressLog) check(t *testing.T) map[Progress]Progress {
+	t.Helper()
+	type key struct {
+		phase Phase
+		index int
+	}
+	last := make(map[key]Progress)
+	for _, p := range l.reports {
+		k := key{p.Phase, p.Index}
+		prev, ok := last[k]
+		if !ok && p.Done != 0 {
+			t.Errorf("%v[%d] first report Done = %d, want 0", p.Phase, p.Index, p.Done)
+		}
+		if ok && p.Done < prev.Done {
+			t.Errorf("%v[%d] Done decreased from %d to %d", p.Phase, p.Index, prev.Done, p.Done)
+		}
+		last[k] = p
+	}
+	ends := make(map[Progress]Progress)
+	for k, p := range last {
+		if p.Done != p.Total {
+			t.Errorf("%v[%d] last report Done = %d, Total = %d", p.Phase, p.Index, p.Done, p.Total)
+		}
+		ends[Progress{Phase: k.phase, Index: k.index}] = p
+	}
+	return ends
+}
+
+func TestDecoder_SetProgress(t *testing.T) {
+	tests := []struct {
+		name      string
+		wantPhase map[Progress]int64
+	}{
+		{"testdata/Cube/glTF/Cube.gltf", map[Progress]int64{
+			{Phase: PhaseJSON}: 3667, {Phase: PhaseBuffer}: 1800, {Phase: PhaseImage}: 891995, {Phase: PhaseImage, Index: 1}: 319,
+		}},
+		{"testdata/BoxVertexColors/glTF-Binary/BoxVertexColors.glb", map[Progress]int64{
+			{Phase: PhaseJSON}: 1628, {Phase: PhaseBuffer}: 1224,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			dir := os.DirFS("testdata/Cube/glTF")
+			cb := func(uri string) (io.ReadCloser, error) { return dir.Open(uri) }
+			log := new(progressLog)
+			if err := NewDecoder(f, cb).SetProgress(log.add).SetParallelism(4).Decode(new(Document)); err != nil {
+				t.Fatalf("Decoder.Decode() error = %v", err)
+			}
+			ends := log.check(t)
+			if len(ends) != len(tt.wantPhase) {
+				t.Errorf("Decoder.Decode() reported %d phases, want %d", len(ends), len(tt.wantPhase))
+			}
+			for k, want := range tt.wantPhase {
+				if got := ends[k].Total; got != want {
+					t.Errorf("Decoder.Decode() %v[%d] total = %d, want %d", k.Phase, k.Index, got, want)
+				}
+			}
+			if log.reports[0].Phase != PhaseJSON {
+				t.Errorf("Decoder.Decode() first phase = %v, want json", log.reports[0].Phase)
+			}
+		})
+	}
+}
+
+func TestEncoder_SetProgress(t *testing.T) {
+	doc := &Document{
+		Asset:   Asset{Version: "2.0"},
+		Buffers: []Buffer{{ByteLength: 3, Data: []uint8{1, 2, 3}}, {ByteLength: 100000, URI: "a.bin", Data: make([]uint8, 100000)}},
+		Images:  []Image{{URI: "a.png", Data: []uint8{4, 5}}},
+	}
+	for _, asBinary := range []bool{false, true} {
+		log := new(progressLog)
+		cb := func(uri string, size int) (io.WriteCloser, error) { return nopWriteCloser{new(bytes.Buffer)}, nil }
+		doc.Buffers[0].URI = ""
+		if !asBinary {
+			doc.Buffers[0].URI = "b.bin"
+		}
+		if err := NewEncoder(new(bytes.Buffer), cb, asBinary).SetProgress(log.add).Encode(doc); err != nil {
+			t.Fatalf("Encoder.Encode() error = %v", err)
+		}
+		ends := log.check(t)
+		want := map[Progress]int64{{Phase: PhaseBuffer}: 3, {Phase: PhaseBuffer, Index: 1}: 100000, {Phase: PhaseImage}: 2}
+		for k, w := range want {
+			if got := ends[k].Total; got != w {
+				t.Errorf("Encoder.Encode() %v[%d] total = %d, want %d", k.Phase, k.Index, got, w)
+			}
+		}
+		if ends[Progress{Phase: PhaseJSON}].Total == 0 {
+			t.Errorf("Encoder.Encode() did not report the JSON content")
+		}
+	}
+}
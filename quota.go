@@ -0,0 +1,124 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// checkDocumentQuotas checks the quotas that only depend on the decoded JSON document.
+func checkDocumentQuotas(q ReadQuotas, doc *Document) error {
+	if q.MaxImageCount > 0 && len(doc.Images) > q.MaxImageCount {
+		return &QuotaError{Quota: "MaxImageCount", Path: "images", Limit: q.MaxImageCount, Value: len(doc.Images)}
+	}
+	if q.MaxTotalAllocation > 0 {
+		var total int
+		for _, b := range doc.Buffers {
+			total += int(b.ByteLength)
+		}
+		if total > q.MaxTotalAllocation {
+			return &QuotaError{Quota: "MaxTotalAllocation", Path: "buffers", Limit: q.MaxTotalAllocation, Value: total}
+		}
+	}
+	return nil
+}
+
+// checkJSONQuotas scans the JSON content for top level arrays longer than MaxArrayLength
+// and for extras and extensions nested deeper than MaxExtrasDepth,
+// so a document over these quotas fails before any of its values is decoded.
+// Malformed content is left to the JSON decoder.
+func checkJSONQuotas(q ReadQuotas, data []byte) error {
+	if q.MaxArrayLength <= 0 && q.MaxExtrasDepth <= 0 {
+		return nil
+	}
+	type frame struct {
+		array      bool
+		extensions bool   // The members of an extensions object are checked like extras.
+		wantKey    bool   // An object is between members.
+		key        []byte // Current member of an object, with its quotes.
+		length     int    // Number of elements of an array.
+		start      int    // Offset of the opening delimiter.
+	}
+	var stack []frame
+	extras, extrasStart := -1, 0 // Stack height and offset of the extras or extension being read, if any.
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case ' ', '\t', '\n', '\r', ':':
+			continue
+		case ',':
+			if n := len(stack); n > 0 && !stack[n-1].array {
+				stack[n-1].wantKey = true
+			}
+			continue
+		case '}', ']':
+			n := len(stack)
+			if n == 0 {
+				return nil
+			}
+			if f := stack[n-1]; n == 2 && f.array && q.MaxArrayLength > 0 && f.length > q.MaxArrayLength {
+				return &QuotaError{Quota: "MaxArrayLength", Path: jsonPath(data, int64(f.start)), Limit: q.MaxArrayLength, Value: f.length}
+			}
+			if stack = stack[:n-1]; len(stack) <= extras {
+				extras = -1
+			}
+			continue
+		}
+		end := i + 1
+		if c == '"' {
+			if end = jsonStringEnd(data, i); end < 0 {
+				return nil
+			}
+		} else {
+			for end < len(data) && bytes.IndexByte([]byte(",:]} \t\n\r"), data[end]) < 0 {
+				end++
+			}
+		}
+		n := len(stack)
+		if n > 0 {
+			if top := &stack[n-1]; top.array {
+				top.length++
+			} else if top.wantKey && c == '"' {
+				top.key, top.wantKey = data[i:end], false
+				i = end - 1
+				continue
+			}
+		}
+		if c == '{' || c == '[' {
+			if n > 0 && extras < 0 && !stack[n-1].array && (stack[n-1].extensions || jsonKeyIs(stack[n-1].key, "extras")) {
+				extras, extrasStart = n, i
+			}
+			f := frame{array: c == '[', wantKey: c == '{', start: i}
+			f.extensions = c == '{' && extras < 0 && n > 0 && !stack[n-1].array && jsonKeyIs(stack[n-1].key, "extensions")
+			stack = append(stack, f)
+			if depth := len(stack) - extras; extras >= 0 && q.MaxExtrasDepth > 0 && depth > q.MaxExtrasDepth {
+				return &QuotaError{Quota: "MaxExtrasDepth", Path: jsonPath(data, int64(extrasStart)), Limit: q.MaxExtrasDepth, Value: depth}
+			}
+			continue
+		}
+		i = end - 1
+	}
+	return nil
+}
+
+// jsonStringEnd returns the offset that follows the JSON string starting at data[start],
+// or -1 if it is not terminated.
+func jsonStringEnd(data []byte, start int) int {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// jsonKeyIs reports whether the quoted JSON string key is name.
+func jsonKeyIs(key []byte, name string) bool {
+	if bytes.IndexByte(key, '\\') < 0 {
+		return len(key) == len(name)+2 && string(key[1:len(key)-1]) == name
+	}
+	var s string
+	return json.Unmarshal(key, &s) == nil && s == name
+}
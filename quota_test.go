@@ -0,0 +1,149 @@
+package gltf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestDecoder_Decode_quotas(t *testing.T) {
+	cb := func(uri string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(make([]byte, 4))), nil
+	}
+	const doc = `{"asset":{"version":"2.0"},"buffers":[{"byteLength":4,"uri":"a.bin"},{"byteLength":4,"uri":"b.bin"}],
+		"nodes":[{"name":"a"},{"name":"b","extras":{"a":[{"b":1}]}}],"images":[{"uri":"a.png"},{"uri":"b.png"}]}`
+	defaults := ReadQuotas{MaxBufferCount: 8, MaxMemoryAllocation: 32}
+	tests := []struct {
+		name      string
+		quotas    func(q *ReadQuotas)
+		wantQuota string
+		wantPath  string
+	}{
+		{"unlimited", func(q *ReadQuotas) {}, "", ""},
+		{"total", func(q *ReadQuotas) { q.MaxTotalAllocation = 7 }, "MaxTotalAllocation", "buffers"},
+		{"totalImages", func(q *ReadQuotas) { q.MaxTotalAllocation = 15 }, "MaxTotalAllocation", "images[1]"},
+		{"totalOk", func(q *ReadQuotas) { q.MaxTotalAllocation = 16 }, "", ""},
+		{"json", func(q *ReadQuotas) { q.MaxJSONSize = 100 }, "MaxJSONSize", ""},
+		{"jsonOk", func(q *ReadQuotas) { q.MaxJSONSize = len(doc) }, "", ""},
+		{"array", func(q *ReadQuotas) { q.MaxArrayLength = 1 }, "MaxArrayLength", "buffers"},
+		{"arrayOk", func(q *ReadQuotas) { q.MaxArrayLength = 2 }, "", ""},
+		{"extras", func(q *ReadQuotas) { q.MaxExtrasDepth = 2 }, "MaxExtrasDepth", "nodes[1].extras"},
+		{"extrasOk", func(q *ReadQuotas) { q.MaxExtrasDepth = 3 }, "", ""},
+		{"imageCount", func(q *ReadQuotas) { q.MaxImageCount = 1 }, "MaxImageCount", "images"},
+		{"imageBytes", func(q *ReadQuotas) { q.MaxImageAllocation = 7 }, "MaxImageAllocation", "images[1]"},
+		{"imageBytesOk", func(q *ReadQuotas) { q.MaxImageAllocation = 8 }, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := defaults
+			tt.quotas(&q)
+			err := NewDecoder(bytes.NewBufferString(doc), cb).SetQuotas(q).Decode(new(Document))
+			if tt.wantQuota == "" {
+				if err != nil {
+					t.Errorf("Decoder.Decode() error = %v", err)
+				}
+				return
+			}
+			var e *QuotaError
+			if !errors.As(err, &e) {
+				t.Fatalf("Decoder.Decode() error = %v, want *QuotaError", err)
+			}
+			if e.Quota != tt.wantQuota || e.Path != tt.wantPath {
+				t.Errorf("Decoder.Decode() quota = %s at %s, want %s at %s", e.Quota, e.Path, tt.wantQuota, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestDecoder_Decode_glbJSONSize(t *testing.T) {
+	data := readFile("testdata/BoxVertexColors/glTF-Binary/BoxVertexColors.glb")
+	err := NewDecoder(bytes.NewReader(data), nil).SetQuotas(ReadQuotas{MaxBufferCount: 8, MaxMemoryAllocation: 1 << 20, MaxJSONSize: 10}).Decode(new(Document))
+	var e *QuotaError
+	if !errors.As(err, &e) || e.Quota != "MaxJSONSize" {
+		t.Errorf("Decoder.Decode() error = %v, want MaxJSONSize *QuotaError", err)
+	}
+}
+
+func TestCheckJSONQuotas(t *testing.T) {
+	nested := strings.Repeat("[", 10000) + strings.Repeat("]", 10000)
+	tests := []struct {
+		name      string
+		quotas    ReadQuotas
+		data      string
+		wantQuota string
+		wantPath  string
+		wantValue int
+	}{
+		{"disabled", ReadQuotas{}, `{"nodes":[{},{},{}],"extras":` + nested + `}`, "", "", 0},
+		{"array", ReadQuotas{MaxArrayLength: 2}, `{"nodes":[{},{"children":[1,2,3]}],"meshes":[{},{},{}]}`, "MaxArrayLength", "meshes", 3},
+		{"arrayNested", ReadQuotas{MaxArrayLength: 2}, `{"nodes":[{"children":[1,2,3]}]}`, "", "", 0},
+		{"arrayStrings", ReadQuotas{MaxArrayLength: 2}, `{"nodes":[{"name":"[,]"},{"name":"\"],["}]}`, "", "", 0},
+		{"extras", ReadQuotas{MaxExtrasDepth: 2}, `{"nodes":[{},{"extras":{"a":[{"b":1}]}}]}`, "MaxExtrasDepth", "nodes[1].extras", 3},
+		{"extrasOk", ReadQuotas{MaxExtrasDepth: 3}, `{"nodes":[{},{"extras":{"a":[{"b":1}]}}]}`, "", "", 0},
+		{"extrasScalar", ReadQuotas{MaxExtrasDepth: 1}, `{"extras":1,"nodes":[{"matrix":[1]}]}`, "", "", 0},
+		{"extrasEscapedKey", ReadQuotas{MaxExtrasDepth: 1}, `{"extr\u0061s":[[1]]}`, "MaxExtrasDepth", "extras", 2},
+		{"extrasDeep", ReadQuotas{MaxExtrasDepth: 8}, `{"asset":{"extras":` + nested + `}}`, "MaxExtrasDepth", "asset.extras", 9},
+		{"extension", ReadQuotas{MaxExtrasDepth: 2}, `{"materials":[{"extensions":{"KHR_a":{"b":[[1]]}}}]}`, "MaxExtrasDepth", "materials[0].extensions.KHR_a", 3},
+		{"extensionOk", ReadQuotas{MaxExtrasDepth: 2}, `{"materials":[{"extensions":{"KHR_a":{"b":[1]},"KHR_c":true}}]}`, "", "", 0},
+		{"extensionExtras", ReadQuotas{MaxExtrasDepth: 2}, `{"extensions":{"KHR_a":{"extras":{"b":[1]}}}}`, "MaxExtrasDepth", "extensions.KHR_a", 3},
+		{"malformed", ReadQuotas{MaxArrayLength: 1, MaxExtrasDepth: 1}, `{"nodes":[{"name":"a}]`, "", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkJSONQuotas(tt.quotas, []byte(tt.data))
+			if tt.wantQuota == "" {
+				if err != nil {
+					t.Errorf("checkJSONQuotas() error = %v", err)
+				}
+				return
+			}
+			var e *QuotaError
+			if !errors.As(err, &e) {
+				t.Fatalf("checkJSONQuotas() error = %v, want *QuotaError", err)
+			}
+			if e.Quota != tt.wantQuota || e.Path != tt.wantPath || e.Value != tt.wantValue {
+				t.Errorf("checkJSONQuotas() = %s at %s (%d), want %s at %s (%d)", e.Quota, e.Path, e.Value, tt.wantQuota, tt.wantPath, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestDecoder_Decode_quotasBeforeDecoding(t *testing.T) {
+	// The nodes are over the quota and would fail to decode, so the quota must be checked first.
+	doc := `{"nodes":[{"mesh":"a"},{"mesh":"b"}]}`
+	err := NewDecoder(bytes.NewBufferString(doc), nil).SetQuotas(ReadQuotas{MaxBufferCount: 8, MaxArrayLength: 1}).Decode(new(Document))
+	var e *QuotaError
+	if !errors.As(err, &e) || e.Quota != "MaxArrayLength" {
+		t.Errorf("Decoder.Decode() error = %v, want MaxArrayLength *QuotaError", err)
+	}
+}
+
+func TestDecoder_SetLazy_quotasPerDocument(t *testing.T) {
+	cb := func(uri string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(make([]byte, 4))), nil
+	}
+	const doc = `{"asset":{"version":"2.0"},"images":[{"uri":"a.png"},{"uri":"b.png"}]}`
+	d := NewDecoder(bytes.NewBufferString(doc), cb).SetLazy(true).SetQuotas(ReadQuotas{MaxBufferCount: 8, MaxMemoryAllocation: 8, MaxImageAllocation: 6})
+	first := new(Document)
+	if err := d.Decode(first); err != nil {
+		t.Fatalf("Decoder.Decode() error = %v", err)
+	}
+	if _, err := first.Images[0].Load(); err != nil {
+		t.Fatalf("Image.Load() error = %v", err)
+	}
+	d.Reset(bytes.NewBufferString(doc), cb)
+	second := new(Document)
+	if err := d.Decode(second); err != nil {
+		t.Fatalf("Decoder.Decode() error = %v", err)
+	}
+	if _, err := second.Images[0].Load(); err != nil {
+		t.Errorf("Image.Load() error = %v, the images of another document were counted", err)
+	}
+	var e *QuotaError
+	if _, err := first.Images[1].Load(); !errors.As(err, &e) || e.Quota != "MaxImageAllocation" {
+		t.Errorf("Image.Load() error = %v, want MaxImageAllocation *QuotaError", err)
+	}
+}
@@ -0,0 +1,143 @@
+package gltf
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+var (
+	// ErrOutsideRoot is returned by a Sandbox when a resource, or the target of a symlink, is outside of its root.
+	ErrOutsideRoot = errors.New("gltf: Resource is outside of the root directory")
+	// ErrNotRegular is returned by a Sandbox when a resource is a directory, a device or any other non regular file.
+	ErrNotRegular = errors.New("gltf: Resource is not a regular file")
+	// ErrTooLarge is returned by a Sandbox when a resource is larger than its MaxSize.
+	ErrTooLarge = errors.New("gltf: Resource is too large")
+)
+
+// A Sandbox confines the reads of a document and its external resources to a root directory.
+// Relative symlinks are followed as long as they do not leave the root, absolute ones are never followed.
+type Sandbox struct {
+	Root    string // Directory that contains the documents and their resources.
+	MaxSize int64  // Maximum size of each resource. Zero means no limit.
+}
+
+// Open will open the glTF or GLB file specified by name, relative to Root, and return the Document.
+// The external resources are read using ReadResource and the document itself is also limited by MaxSize.
+func (s *Sandbox) Open(name string) (*Document, error) {
+	name = path.Clean(filepath.ToSlash(name))
+	f, err := s.open(name)
+	if err != nil {
+		return nil, err
+	}
+	dir := path.Dir(name)
+	cb := func(uri string) (io.ReadCloser, error) {
+		return s.ReadResource(dir, uri)
+	}
+	d := NewDecoder(f, cb)
+	d.sandboxed = true
+	doc := new(Document)
+	err = d.Decode(doc)
+	f.Close()
+	return doc, err
+}
+
+// ReadResource opens the resource identified by the relative URI uri,
+// which is resolved against dir, a slash-separated directory relative to Root.
+// Unlike the Decoder, which only reads from the document directory, the resource can be anywhere inside Root,
+// and so can the resources of the documents read with Open.
+// The errors are *fs.PathError, whose underlying error is one of ErrOutsideRoot, ErrNotRegular, ErrTooLarge
+// or the one returned by the file system.
+func (s *Sandbox) ReadResource(dir, uri string) (io.ReadCloser, error) {
+	p, err := uriPath(uri)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: uri, Err: err}
+	}
+	return s.open(path.Join(dir, p))
+}
+
+func (s *Sandbox) open(name string) (io.ReadCloser, error) {
+	if name == ".." || strings.HasPrefix(name, "../") || path.IsAbs(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: ErrOutsideRoot}
+	}
+	// os.Root resolves the name and its symlinks atomically, so they cannot be swapped to leave the root.
+	root, err := os.OpenRoot(s.Root)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	name = filepath.FromSlash(name)
+	// The file is checked before opening it, as opening a device or a pipe can block or have side effects,
+	// and after opening it, in case it was replaced in between.
+	info, err := root.Stat(name)
+	if err != nil {
+		return nil, rootError(name, err)
+	}
+	if err = s.check(name, info); err != nil {
+		return nil, err
+	}
+	f, err := root.Open(name)
+	if err != nil {
+		return nil, rootError(name, err)
+	}
+	if info, err = f.Stat(); err == nil {
+		err = s.check(name, info)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if s.MaxSize > 0 {
+		return &sandboxFile{File: f, name: name, left: s.MaxSize}, nil
+	}
+	return f, nil
+}
+
+// rootError replaces the error of os.Root for the names that escape from it, which is not exported,
+// with ErrOutsideRoot. The other errors come from the system.
+func rootError(name string, err error) error {
+	var pathErr *fs.PathError
+	var errno syscall.Errno
+	if errors.As(err, &pathErr) && !errors.As(pathErr.Err, &errno) {
+		return &fs.PathError{Op: "open", Path: name, Err: ErrOutsideRoot}
+	}
+	return err
+}
+
+func (s *Sandbox) check(name string, info fs.FileInfo) error {
+	if !info.Mode().IsRegular() {
+		return &fs.PathError{Op: "open", Path: name, Err: ErrNotRegular}
+	}
+	if s.MaxSize > 0 && info.Size() > s.MaxSize {
+		return &fs.PathError{Op: "open", Path: name, Err: ErrTooLarge}
+	}
+	return nil
+}
+
+// sandboxFile fails if the file grows over MaxSize after being opened.
+type sandboxFile struct {
+	*os.File
+	name string
+	left int64
+}
+
+// Read reads one byte past the remaining size to detect the growth,
+// but never returns it, and keeps failing once the file is over MaxSize.
+func (f *sandboxFile) Read(p []byte) (int, error) {
+	if f.left < 0 {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: ErrTooLarge}
+	}
+	if int64(len(p)) > f.left+1 {
+		p = p[:f.left+1]
+	}
+	n, err := f.File.Read(p)
+	if f.left -= int64(n); f.left < 0 {
+		return max(n-1, 0), &fs.PathError{Op: "read", Path: f.name, Err: ErrTooLarge}
+	}
+	return n, err
+}
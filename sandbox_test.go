@@ -0,0 +1,146 @@
+package gltf
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSandbox(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gltf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	root := filepath.Join(dir, "root")
+	files := map[string]string{
+		"root/models/a.gltf":    `{"asset":{"version":"2.0"},"buffers":[{"byteLength":3,"uri":"a.bin"}]}`,
+		"root/models/a.bin":     "abc",
+		"root/models/b.gltf":    `{"asset":{"version":"2.0"},"buffers":[{"byteLength":3,"uri":"../shared/b.bin"}]}`,
+		"root/models/c.gltf":    `{"asset":{"version":"2.0"},"buffers":[{"byteLength":6,"uri":"../../secret.bin"}]}`,
+		"root/shared/b.bin":     "abc",
+		"root/shared/big.bin":   "abcdefghij",
+		"root/models/dir.bin/x": "",
+		"secret.bin":            "secret",
+	}
+	for name, data := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"root/models/inside.bin":   "../shared/../models/a.bin",
+		"root/models/absolute.bin": filepath.Join(root, "models", "a.bin"),
+		"root/models/outside.bin":  filepath.Join(dir, "secret.bin"),
+		"root/models/escape":       dir,
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+	s := &Sandbox{Root: root, MaxSize: 5}
+	tests := []struct {
+		uri     string
+		want    string
+		wantErr error
+	}{
+		{"a.bin", "abc", nil},
+		{"inside.bin", "abc", nil},
+		{"../shared/../models/a.bin", "abc", nil},
+		{"../shared/big.bin", "", ErrTooLarge},
+		{"absolute.bin", "", ErrOutsideRoot},
+		{"outside.bin", "", ErrOutsideRoot},
+		{"escape/secret.bin", "", ErrOutsideRoot},
+		{"../../secret.bin", "", ErrOutsideRoot},
+		{"dir.bin", "", ErrNotRegular},
+		{"missing.bin", "", os.ErrNotExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			r, err := s.ReadResource("models", tt.uri)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Sandbox.ReadResource() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Sandbox.ReadResource() error = %v", err)
+			}
+			defer r.Close()
+			if got, err := ioutil.ReadAll(r); err != nil || string(got) != tt.want {
+				t.Errorf("Sandbox.ReadResource() = %s, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+	s = &Sandbox{Root: root}
+	doc, err := s.Open("models/b.gltf")
+	if err != nil {
+		t.Fatalf("Sandbox.Open() error = %v", err)
+	}
+	if got := string(doc.Buffers[0].Data); got != "abc" {
+		t.Errorf("Sandbox.Open() buffer = %s, want abc", got)
+	}
+	if _, err = s.Open("models/c.gltf"); !errors.Is(err, ErrOutsideRoot) {
+		t.Errorf("Sandbox.Open() error = %v, want ErrOutsideRoot", err)
+	}
+}
+
+func TestSandbox_Open(t *testing.T) {
+	s := &Sandbox{Root: "testdata"}
+	doc, err := s.Open("Cube/glTF/Cube.gltf")
+	if err != nil {
+		t.Fatalf("Sandbox.Open() error = %v", err)
+	}
+	want, _ := Open("testdata/Cube/glTF/Cube.gltf")
+	if diff := deepEqualDocument(doc, want); diff != nil {
+		t.Errorf("Sandbox.Open() = %v", diff)
+	}
+	if _, err := (&Sandbox{Root: "testdata/Cube"}).Open("../Cameras/glTF/Cameras.gltf"); !errors.Is(err, ErrOutsideRoot) {
+		t.Errorf("Sandbox.Open() error = %v, want ErrOutsideRoot", err)
+	}
+	if _, err := (&Sandbox{Root: "testdata", MaxSize: 100}).Open("Cube/glTF/Cube.gltf"); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Sandbox.Open() error = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestSandboxFile_Read(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gltf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "a.bin")
+	if err := ioutil.WriteFile(name, []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rc, err := (&Sandbox{Root: dir, MaxSize: 4}).open("a.bin")
+	if err != nil {
+		t.Fatalf("Sandbox.open() error = %v", err)
+	}
+	defer rc.Close()
+	// The file grows over MaxSize after being opened.
+	if err := ioutil.WriteFile(name, []byte("abcdefgh"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := make([]byte, 16)
+	n, err := rc.Read(p)
+	if n != 4 || !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("sandboxFile.Read() = %d, %v, want 4, ErrTooLarge", n, err)
+	}
+	if string(p[:n]) != "abcd" {
+		t.Errorf("sandboxFile.Read() data = %q, want %q", p[:n], "abcd")
+	}
+	for i := 0; i < 2; i++ {
+		if n, err := rc.Read(p); n != 0 || !errors.Is(err, ErrTooLarge) {
+			t.Errorf("sandboxFile.Read() = %d, %v, want 0, ErrTooLarge", n, err)
+		}
+	}
+}
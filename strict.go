@@ -0,0 +1,174 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+var (
+	documentType  = reflect.TypeOf(Document{})
+	interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+	strictFields  sync.Map // map[reflect.Type]map[string]reflect.Type
+)
+
+// checkStrict walks the JSON content of a document looking for properties that are unknown,
+// duplicated or of the wrong type.
+func checkStrict(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return (&strictChecker{dec: dec}).value(documentType, "")
+}
+
+type strictChecker struct {
+	dec *json.Decoder
+}
+
+func (c *strictChecker) value(t reflect.Type, path string) error {
+	tok, err := c.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		// Like encoding/json, null leaves the optional values unset.
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+			return nil
+		}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		switch tok {
+		case json.Delim('{'):
+			return c.object(path, func(_, path string) error { return c.value(interfaceType, path) })
+		case json.Delim('['):
+			return c.array(interfaceType, path)
+		}
+	case reflect.Struct:
+		if tok != json.Delim('{') {
+			return c.wrongType(path, "object", tok)
+		}
+		fields := structFields(t)
+		return c.object(path, func(key, path string) error {
+			f, ok := fields[key]
+			if !ok {
+				return &StrictError{Path: path, Reason: "unknown property"}
+			}
+			return c.value(f, path)
+		})
+	case reflect.Map:
+		if tok != json.Delim('{') {
+			return c.wrongType(path, "object", tok)
+		}
+		return c.object(path, func(_, path string) error { return c.value(t.Elem(), path) })
+	case reflect.Slice, reflect.Array:
+		if tok != json.Delim('[') {
+			return c.wrongType(path, "array", tok)
+		}
+		return c.array(t.Elem(), path)
+	case reflect.String:
+		if _, ok := tok.(string); !ok {
+			return c.wrongType(path, "string", tok)
+		}
+	case reflect.Bool:
+		if _, ok := tok.(bool); !ok {
+			return c.wrongType(path, "boolean", tok)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := tok.(json.Number); !ok {
+			return c.wrongType(path, "integer", tok)
+		} else if _, err := strconv.ParseInt(string(n), 10, t.Bits()); err != nil {
+			return c.wrongType(path, "integer", tok)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, ok := tok.(json.Number); !ok {
+			return c.wrongType(path, "unsigned integer", tok)
+		} else if _, err := strconv.ParseUint(string(n), 10, t.Bits()); err != nil {
+			return c.wrongType(path, "unsigned integer", tok)
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := tok.(json.Number); !ok {
+			return c.wrongType(path, "number", tok)
+		}
+	}
+	return nil
+}
+
+// object checks the members of an object whose opening delimiter has already been read.
+func (c *strictChecker) object(path string, member func(key, path string) error) error {
+	seen := make(map[string]bool)
+	for c.dec.More() {
+		tok, err := c.dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+		if seen[key] {
+			return &StrictError{Path: keyPath, Reason: "duplicated property"}
+		}
+		seen[key] = true
+		if err := member(key, keyPath); err != nil {
+			return err
+		}
+	}
+	_, err := c.dec.Token()
+	return err
+}
+
+// array checks the elements of an array whose opening delimiter has already been read.
+func (c *strictChecker) array(elem reflect.Type, path string) error {
+	for i := 0; c.dec.More(); i++ {
+		if err := c.value(elem, indexPath(path, i)); err != nil {
+			return err
+		}
+	}
+	_, err := c.dec.Token()
+	return err
+}
+
+// wrongType returns the error of a value that does not have the expected type.
+func (c *strictChecker) wrongType(path, want string, tok json.Token) error {
+	got := "null"
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '{' {
+			got = "object"
+		} else {
+			got = "array"
+		}
+	case string:
+		got = "string"
+	case bool:
+		got = "boolean"
+	case json.Number:
+		got = "number " + tok.String()
+	}
+	return &StrictError{Path: path, Reason: fmt.Sprintf("wrong type, want %s, got %s", want, got)}
+}
+
+// structFields returns the JSON properties of a struct type.
+func structFields(t reflect.Type) map[string]reflect.Type {
+	if fields, ok := strictFields.Load(t); ok {
+		return fields.(map[string]reflect.Type)
+	}
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || f.Tag.Get("json") == "-" {
+			continue
+		}
+		fields[jsonTagName(f)] = f.Type
+	}
+	strictFields.Store(t, fields)
+	return fields
+}
@@ -0,0 +1,74 @@
+package gltf
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDecoder_SetStrict(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		wantPath string
+		wantErr  bool
+	}{
+		{"base", `{"asset":{"version":"2.0"},"nodes":[{"name":"a","rotation":[0,0,0,1]}],"meshes":[{"primitives":[{"attributes":{"POSITION":0}}]}]}`, "", false},
+		{"extras", `{"asset":{"version":"2.0","extras":{"a":[1,"b",{"c":null}]}}}`, "", false},
+		{"extensions", `{"asset":{"version":"2.0"},"extensions":{"EXT_other":{"any":true}}}`, "", false},
+		{"unknown", `{"asset":{"version":"2.0"},"bufferViews":[{"buffer":0,"byteLength":1,"byteOfset":2}]}`, "bufferViews[0].byteOfset", true},
+		{"caseSensitive", `{"asset":{"version":"2.0"},"buffers":[{"byteLength":1,"URI":"a.bin"}]}`, "buffers[0].URI", true},
+		{"duplicated", `{"asset":{"version":"2.0"},"nodes":[{},{"name":"a","name":"b"}]}`, "nodes[1].name", true},
+		{"duplicatedExtras", `{"asset":{"version":"2.0","extras":{"a":1,"a":2}}}`, "asset.extras.a", true},
+		{"duplicatedAttribute", `{"asset":{"version":"2.0"},"meshes":[{"primitives":[{"attributes":{"POSITION":0,"POSITION":1}}]}]}`, "meshes[0].primitives[0].attributes.POSITION", true},
+		{"string", `{"asset":{"version":2}}`, "asset.version", true},
+		{"unsigned", `{"asset":{"version":"2.0"},"buffers":[{"byteLength":-1}]}`, "buffers[0].byteLength", true},
+		{"integer", `{"asset":{"version":"2.0"},"scene":1.5}`, "scene", true},
+		{"number", `{"asset":{"version":"2.0"},"nodes":[{"scale":[1,"1",1]}]}`, "nodes[0].scale[1]", true},
+		{"array", `{"asset":{"version":"2.0"},"nodes":{}}`, "nodes", true},
+		{"object", `{"asset":[]}`, "asset", true},
+		{"null", `{"asset":{"version":"2.0"},"nodes":[null]}`, "nodes[0]", true},
+		{"nullPointer", `{"asset":{"version":"2.0"},"materials":[{"pbrMetallicRoughness":null,"normalTexture":null}]}`, "", false},
+		{"nullSlice", `{"asset":{"version":"2.0"},"nodes":null,"scenes":[{"nodes":null}]}`, "", false},
+		{"nullMap", `{"asset":{"version":"2.0"},"meshes":[{"primitives":[{"attributes":null,"extensions":null}]}]}`, "", false},
+		{"nullInteger", `{"asset":{"version":"2.0"},"scene":null}`, "scene", true},
+		{"nullString", `{"asset":{"version":null}}`, "asset.version", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewDecoder(bytes.NewBufferString(tt.json), nil).SetStrict(true).Decode(new(Document))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Decoder.Decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var e *StrictError
+			if tt.wantErr && (!errors.As(err, &e) || e.Path != tt.wantPath) {
+				t.Errorf("Decoder.Decode() error = %v, want *StrictError at %s", err, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestDecoder_SetStrict_testdata(t *testing.T) {
+	for _, name := range []string{
+		"testdata/BoxVertexColors/glTF-Binary/BoxVertexColors.glb",
+		"testdata/Cameras/glTF/Cameras.gltf",
+		"testdata/OrientationTest/glTF/OrientationTest.gltf",
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := NewDecoder(bytes.NewReader(readFile(name)), readCallback).SetStrict(true).Decode(new(Document))
+			var e *StrictError
+			if errors.As(err, &e) {
+				t.Errorf("Decoder.Decode() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestDecoder_SetStrict_syntaxError(t *testing.T) {
+	err := NewDecoder(bytes.NewBufferString(`{"asset":{"version":"2.0"},}`), nil).SetStrict(true).Decode(new(Document))
+	var e *SyntaxError
+	if !errors.As(err, &e) {
+		t.Errorf("Decoder.Decode() error = %v, want *SyntaxError", err)
+	}
+}
@@ -1,11 +1,9 @@
 package gltf
 
 import (
-	"bytes"
-	"encoding/base64"
+	"context"
 	"encoding/json"
-	"fmt"
-	"strings"
+	"net/http"
 )
 
 // An Asset is metadata about the glTF asset.
@@ -39,6 +37,16 @@ type Document struct {
 	Scenes             []Scene      `json:"scenes,omitempty" validate:"dive"`
 	Skins              []Skin       `json:"skins,omitempty" validate:"dive"`
 	Textures           []Texture    `json:"textures,omitempty" validate:"dive"`
+	Chunks             []Chunk      `json:"-"` // Unknown chunks of a GLB file, which are written back after the BIN chunk.
+	raw                []byte       // Original JSON content kept by a preserving Decoder.
+}
+
+// A Chunk is a GLB chunk other than the JSON and the BIN chunks.
+type Chunk struct {
+	Type uint32 // Chunk type, such as 0x004e4942 for BIN.
+	// Data is padded with zeros to a multiple of 4 bytes when encoded, as required by the GLB format,
+	// which does not record the original length, so the padding is part of the decoded data.
+	Data []uint8
 }
 
 // UnmarshalJSON unmarshal the document with the correct default values.
@@ -150,16 +158,37 @@ type Buffer struct {
 	URI        string      `json:"uri,omitempty" validate:"omitempty"`
 	ByteLength uint32      `json:"byteLength" validate:"required"`
 	Data       []uint8     `json:"-"`
+	loader     func(context.Context) ([]uint8, error)
+	releasable bool // Data is a BIN chunk that can be given back with Decoder.Release.
+}
+
+// Load returns the buffer data.
+// If the buffer was decoded by a lazy Decoder the data is read on the first call.
+// Load is not safe for concurrent use.
+func (b *Buffer) Load() ([]uint8, error) {
+	return b.LoadContext(context.Background())
+}
+
+// LoadContext is like Load but aborts the read as soon as ctx is done.
+func (b *Buffer) LoadContext(ctx context.Context) ([]uint8, error) {
+	if b.Data == nil && b.loader != nil {
+		data, err := b.loader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		b.Data, b.loader = data, nil
+	}
+	return b.Data, nil
 }
 
 // IsEmbeddedResource returns true if the buffer points to an embedded resource.
 func (b *Buffer) IsEmbeddedResource() bool {
-	return strings.HasPrefix(b.URI, mimetypeApplicationOctet)
+	return IsDataURI(b.URI)
 }
 
 // EmbeddedResource defines the buffer as an embedded resource and encodes the URI so it points to the the resource.
 func (b *Buffer) EmbeddedResource() {
-	b.URI = fmt.Sprintf("%s,%s", mimetypeApplicationOctet, base64.StdEncoding.EncodeToString(b.Data))
+	b.URI = EncodeDataURI(mimetypeApplicationOctet, b.Data)
 }
 
 // marshalData decode the buffer from the URI. If the buffer is not en embedded resource the returned array will be empty.
@@ -167,8 +196,11 @@ func (b *Buffer) marshalData() ([]uint8, error) {
 	if !b.IsEmbeddedResource() {
 		return []uint8{}, nil
 	}
-	startPos := len(mimetypeApplicationOctet) + 1
-	return base64.StdEncoding.DecodeString(b.URI[startPos:])
+	d, err := ParseDataURI(b.URI)
+	if err != nil {
+		return []uint8{}, err
+	}
+	return d.Data, nil
 }
 
 // BufferView is a view into a buffer generally representing a subset of the buffer.
@@ -180,6 +212,7 @@ type BufferView struct {
 	ByteLength uint32      `json:"byteLength" validate:"required"`
 	ByteStride uint32      `json:"byteStride,omitempty" validate:"omitempty,gte=4,lte=252"`
 	Target     Target      `json:"target,omitempty" validate:"omitempty,oneof=34962 34963"`
+	Data       []uint8     `json:"-"` // Content of the view to be laid out by Pack instead of the buffer bytes.
 }
 
 // UnmarshalJSON unmarshal the buffer view with the correct default values.
@@ -259,32 +292,25 @@ func (n *Node) UnmarshalJSON(data []byte) error {
 // MarshalJSON marshal the node with the correct default values.
 func (n *Node) MarshalJSON() ([]byte, error) {
 	type alias Node
-	out, err := json.Marshal(&struct{ *alias }{alias: (*alias)(n)})
-	if err == nil {
-		if n.Matrix == [16]float64{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1} {
-			out = removeProperty([]byte(`"matrix":[1,0,0,0,0,1,0,0,0,0,1,0,0,0,0,1]`), out)
-		}
-		if n.Rotation == [4]float64{0, 0, 0, 1} {
-			out = removeProperty([]byte(`"rotation":[0,0,0,1]`), out)
-		}
-		if n.Scale == [3]float64{1, 1, 1} {
-			out = removeProperty([]byte(`"scale":[1,1,1]`), out)
-		}
-		if n.Translation == [3]float64{0, 0, 0} {
-			out = removeProperty([]byte(`"translation":[0,0,0]`), out)
-		}
-		if n.Camera == -1 {
-			out = removeProperty([]byte(`"camera":-1`), out)
-		}
-		if n.Skin == -1 {
-			out = removeProperty([]byte(`"skin":-1`), out)
-		}
-		if n.Mesh == -1 {
-			out = removeProperty([]byte(`"mesh":-1`), out)
-		}
-		out = sanitizeJSON(out)
-	}
-	return out, err
+	return json.Marshal(&struct {
+		Camera      *int32       `json:"camera,omitempty"`
+		Skin        *int32       `json:"skin,omitempty"`
+		Matrix      *[16]float64 `json:"matrix,omitempty"`
+		Mesh        *int32       `json:"mesh,omitempty"`
+		Rotation    *[4]float64  `json:"rotation,omitempty"`
+		Scale       *[3]float64  `json:"scale,omitempty"`
+		Translation *[3]float64  `json:"translation,omitempty"`
+		*alias
+	}{
+		Camera:      omitDefault(&n.Camera, -1),
+		Skin:        omitDefault(&n.Skin, -1),
+		Matrix:      omitDefault(&n.Matrix, [16]float64{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}),
+		Mesh:        omitDefault(&n.Mesh, -1),
+		Rotation:    omitDefault(&n.Rotation, [4]float64{0, 0, 0, 1}),
+		Scale:       omitDefault(&n.Scale, [3]float64{1, 1, 1}),
+		Translation: omitDefault(&n.Translation, [3]float64{0, 0, 0}),
+		alias:       (*alias)(n),
+	})
 }
 
 // Skin defines joints and matrices.
@@ -316,17 +342,15 @@ func (s *Skin) UnmarshalJSON(data []byte) error {
 // MarshalJSON marshal the skin with the correct default values.
 func (s *Skin) MarshalJSON() ([]byte, error) {
 	type alias Skin
-	out, err := json.Marshal(&struct{ *alias }{alias: (*alias)(s)})
-	if err == nil {
-		if s.InverseBindMatrices == -1 {
-			out = removeProperty([]byte(`"inverseBindMatrices":-1`), out)
-		}
-		if s.Skeleton == -1 {
-			out = removeProperty([]byte(`"skeleton":-1`), out)
-		}
-		out = sanitizeJSON(out)
-	}
-	return out, err
+	return json.Marshal(&struct {
+		InverseBindMatrices *int32 `json:"inverseBindMatrices,omitempty"`
+		Skeleton            *int32 `json:"skeleton,omitempty"`
+		*alias
+	}{
+		InverseBindMatrices: omitDefault(&s.InverseBindMatrices, -1),
+		Skeleton:            omitDefault(&s.Skeleton, -1),
+		alias:               (*alias)(s),
+	})
 }
 
 // A Camera projection. A node can reference a camera to apply a transform to place the camera in the scene.
@@ -398,17 +422,17 @@ func (p *Primitive) UnmarshalJSON(data []byte) error {
 // MarshalJSON marshal the primitive with the correct default values.
 func (p *Primitive) MarshalJSON() ([]byte, error) {
 	type alias Primitive
-	out, err := json.Marshal(&struct{ *alias }{alias: (*alias)(p)})
-	if err == nil {
-		if p.Indices == -1 {
-			out = removeProperty([]byte(`"indices":-1`), out)
-		}
-		if p.Material == -1 {
-			out = removeProperty([]byte(`"material":-1`), out)
-		}
-		out = sanitizeJSON(out)
-	}
-	return out, err
+	return json.Marshal(&struct {
+		Attributes Attribute `json:"attributes"`
+		Indices    *int32    `json:"indices,omitempty"`
+		Material   *int32    `json:"material,omitempty"`
+		*alias
+	}{
+		Attributes: p.Attributes,
+		Indices:    omitDefault(&p.Indices, -1),
+		Material:   omitDefault(&p.Material, -1),
+		alias:      (*alias)(p),
+	})
 }
 
 // The Material appearance of a primitive.
@@ -445,20 +469,21 @@ func (m *Material) UnmarshalJSON(data []byte) error {
 // MarshalJSON marshal the material with the correct default values.
 func (m *Material) MarshalJSON() ([]byte, error) {
 	type alias Material
-	out, err := json.Marshal(&struct{ *alias }{alias: (*alias)(m)})
-	if err == nil {
-		if m.AlphaCutoff == 0.5 {
-			out = removeProperty([]byte(`"alphaCutoff":0.5`), out)
-		}
-		if m.AlphaMode == Opaque {
-			out = removeProperty([]byte(`"alphaMode":"OPAQUE"`), out)
-		}
-		if m.EmissiveFactor == [3]float64{0, 0, 0} {
-			out = removeProperty([]byte(`"emissiveFactor":[0,0,0]`), out)
-		}
-		out = sanitizeJSON(out)
-	}
-	return out, err
+	alphaMode := omitDefault(&m.AlphaMode, Opaque)
+	if m.AlphaMode == "" {
+		alphaMode = nil
+	}
+	return json.Marshal(&struct {
+		EmissiveFactor *[3]float64 `json:"emissiveFactor,omitempty"`
+		AlphaMode      *AlphaMode  `json:"alphaMode,omitempty"`
+		AlphaCutoff    *float64    `json:"alphaCutoff,omitempty"`
+		*alias
+	}{
+		EmissiveFactor: omitDefault(&m.EmissiveFactor, [3]float64{0, 0, 0}),
+		AlphaMode:      alphaMode,
+		AlphaCutoff:    omitDefault(&m.AlphaCutoff, 0.5),
+		alias:          (*alias)(m),
+	})
 }
 
 // A NormalTexture references to a normal texture.
@@ -489,17 +514,15 @@ func (n *NormalTexture) UnmarshalJSON(data []byte) error {
 // MarshalJSON marshal the texture info with the correct default values.
 func (n *NormalTexture) MarshalJSON() ([]byte, error) {
 	type alias NormalTexture
-	out, err := json.Marshal(&struct{ *alias }{alias: (*alias)(n)})
-	if err == nil {
-		if n.Index == -1 {
-			out = removeProperty([]byte(`"index":-1`), out)
-		}
-		if n.Scale == -1 {
-			out = removeProperty([]byte(`"scale":-1`), out)
-		}
-		out = sanitizeJSON(out)
-	}
-	return out, err
+	return json.Marshal(&struct {
+		Index *int32   `json:"index,omitempty"`
+		Scale *float64 `json:"scale,omitempty"`
+		*alias
+	}{
+		Index: omitDefault(&n.Index, -1),
+		Scale: omitDefault(&n.Scale, -1),
+		alias: (*alias)(n),
+	})
 }
 
 // An OcclusionTexture references to an occlusion texture
@@ -530,17 +553,15 @@ func (o *OcclusionTexture) UnmarshalJSON(data []byte) error {
 // MarshalJSON marshal the texture info with the correct default values.
 func (o *OcclusionTexture) MarshalJSON() ([]byte, error) {
 	type alias OcclusionTexture
-	out, err := json.Marshal(&struct{ *alias }{alias: (*alias)(o)})
-	if err == nil {
-		if o.Index == -1 {
-			out = removeProperty([]byte(`"index":-1`), out)
-		}
-		if o.Strength == 1 {
-			out = removeProperty([]byte(`"strength":1`), out)
-		}
-		out = sanitizeJSON(out)
-	}
-	return out, err
+	return json.Marshal(&struct {
+		Index    *int32   `json:"index,omitempty"`
+		Strength *float64 `json:"strength,omitempty"`
+		*alias
+	}{
+		Index:    omitDefault(&o.Index, -1),
+		Strength: omitDefault(&o.Strength, 1),
+		alias:    (*alias)(o),
+	})
 }
 
 // PBRMetallicRoughness defines a set of parameter values that are used to define the metallic-roughness material model from Physically-Based Rendering (PBR) methodology.
@@ -573,20 +594,17 @@ func (p *PBRMetallicRoughness) UnmarshalJSON(data []byte) error {
 // MarshalJSON marshal the pbr with the correct default values.
 func (p *PBRMetallicRoughness) MarshalJSON() ([]byte, error) {
 	type alias PBRMetallicRoughness
-	out, err := json.Marshal(&struct{ *alias }{alias: (*alias)(p)})
-	if err == nil {
-		if p.MetallicFactor == 1 {
-			out = removeProperty([]byte(`"metallicFactor":1`), out)
-		}
-		if p.RoughnessFactor == 1 {
-			out = removeProperty([]byte(`"roughnessFactor":1`), out)
-		}
-		if p.BaseColorFactor == [4]float64{1, 1, 1, 1} {
-			out = removeProperty([]byte(`"baseColorFactor":[1,1,1,1]`), out)
-		}
-		out = sanitizeJSON(out)
-	}
-	return out, err
+	return json.Marshal(&struct {
+		BaseColorFactor *[4]float64 `json:"baseColorFactor,omitempty"`
+		MetallicFactor  *float64    `json:"metallicFactor,omitempty"`
+		RoughnessFactor *float64    `json:"roughnessFactor,omitempty"`
+		*alias
+	}{
+		BaseColorFactor: omitDefault(&p.BaseColorFactor, [4]float64{1, 1, 1, 1}),
+		MetallicFactor:  omitDefault(&p.MetallicFactor, 1),
+		RoughnessFactor: omitDefault(&p.RoughnessFactor, 1),
+		alias:           (*alias)(p),
+	})
 }
 
 // TextureInfo references to a texture.
@@ -656,17 +674,15 @@ func (t *Texture) UnmarshalJSON(data []byte) error {
 // MarshalJSON marshal the texture with the correct default values.
 func (t *Texture) MarshalJSON() ([]byte, error) {
 	type alias Texture
-	out, err := json.Marshal(&struct{ *alias }{alias: (*alias)(t)})
-	if err == nil {
-		if t.Sampler == -1 {
-			out = removeProperty([]byte(`"sampler":-1`), out)
-		}
-		if t.Source == -1 {
-			out = removeProperty([]byte(`"source":-1`), out)
-		}
-		out = sanitizeJSON(out)
-	}
-	return out, err
+	return json.Marshal(&struct {
+		Sampler *int32 `json:"sampler,omitempty"`
+		Source  *int32 `json:"source,omitempty"`
+		*alias
+	}{
+		Sampler: omitDefault(&t.Sampler, -1),
+		Source:  omitDefault(&t.Source, -1),
+		alias:   (*alias)(t),
+	})
 }
 
 // Sampler of a texture for filtering and wrapping modes.
@@ -705,11 +721,42 @@ type Image struct {
 	URI        string      `json:"uri,omitempty" validate:"omitempty"`
 	MimeType   string      `json:"mimeType,omitempty" validate:"omitempty,oneof=image/jpeg image/png"` // Manadatory if BufferView is defined.
 	BufferView uint32      `json:"bufferView,omitempty"`                                               // Use this instead of the image's uri property.
+	Data       []uint8     `json:"-"`
+	loader     func(context.Context) ([]uint8, error)
+}
+
+// Load returns the image data.
+// If the image was decoded by a lazy Decoder the data is read on the first call.
+// Load is not safe for concurrent use.
+func (im *Image) Load() ([]uint8, error) {
+	return im.LoadContext(context.Background())
+}
+
+// LoadContext is like Load but aborts the read as soon as ctx is done.
+func (im *Image) LoadContext(ctx context.Context) ([]uint8, error) {
+	if im.Data == nil && im.loader != nil {
+		data, err := im.loader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		im.Data, im.loader = data, nil
+	}
+	return im.Data, nil
 }
 
 // IsEmbeddedResource returns true if the buffer points to an embedded resource.
 func (im *Image) IsEmbeddedResource() bool {
-	return strings.HasPrefix(im.URI, mimetypeImagePNG) || strings.HasPrefix(im.URI, mimetypeImageJPG)
+	return IsDataURI(im.URI)
+}
+
+// EmbeddedResource defines the image as an embedded resource and encodes the URI so it points to the the resource.
+// The media type is MimeType or, if it is empty, the one detected from the data.
+func (im *Image) EmbeddedResource() {
+	mimeType := im.MimeType
+	if mimeType == "" {
+		mimeType = http.DetectContentType(im.Data)
+	}
+	im.URI = EncodeDataURI(mimeType, im.Data)
 }
 
 // MarshalData decode the image from the URI. If the image is not en embedded resource the returned array will be empty.
@@ -717,12 +764,11 @@ func (im *Image) MarshalData() ([]uint8, error) {
 	if !im.IsEmbeddedResource() {
 		return []uint8{}, nil
 	}
-	mimetype := mimetypeImagePNG
-	if strings.HasPrefix(im.URI, mimetypeImageJPG) {
-		mimetype = mimetypeImageJPG
+	d, err := ParseDataURI(im.URI)
+	if err != nil {
+		return []uint8{}, err
 	}
-	startPos := len(mimetype) + 1
-	return base64.StdEncoding.DecodeString(im.URI[startPos:])
+	return d.Data, nil
 }
 
 // An Animation keyframe.
@@ -841,12 +887,11 @@ func (ch *ChannelTarget) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct{ *alias }{alias: (*alias)(ch)})
 }
 
-func removeProperty(str []byte, b []byte) []byte {
-	b = bytes.Replace(b, str, []byte(""), 1)
-	return bytes.Replace(b, []byte(`,,`), []byte(","), 1)
-}
-
-func sanitizeJSON(b []byte) []byte {
-	b = bytes.Replace(b, []byte(`{,`), []byte("{"), 1)
-	return bytes.Replace(b, []byte(`,}`), []byte("}"), 1)
+// omitDefault returns v unless it points to the default value def,
+// in which case it returns nil so the property is omitted by the omitempty option.
+func omitDefault[T comparable](v *T, def T) *T {
+	if *v == def {
+		return nil
+	}
+	return v
 }
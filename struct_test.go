@@ -1,6 +1,8 @@
 package gltf
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -12,6 +14,7 @@ func TestBuffer_IsEmbeddedResource(t *testing.T) {
 		want bool
 	}{
 		{"embedded", &Buffer{URI: "data:application/octet-stream;base64,dsjdsaGGUDXGA"}, true},
+		{"gltfBuffer", &Buffer{URI: "data:application/gltf-buffer;base64,dsjdsaGGUDXGA"}, true},
 		{"external", &Buffer{URI: "https://web.com/a"}, false},
 	}
 	for _, tt := range tests {
@@ -41,6 +44,44 @@ func TestBuffer_EmbeddedResource(t *testing.T) {
 	}
 }
 
+func TestBuffer_Load(t *testing.T) {
+	var calls int
+	loader := func(ctx context.Context) ([]uint8, error) {
+		calls++
+		return []uint8{1, 2}, nil
+	}
+	tests := []struct {
+		name      string
+		b         *Buffer
+		want      []uint8
+		wantCalls int
+		wantErr   bool
+	}{
+		{"loaded", &Buffer{Data: []uint8{3}}, []uint8{3}, 0, false},
+		{"empty", &Buffer{}, nil, 0, false},
+		{"lazy", &Buffer{loader: loader}, []uint8{1, 2}, 1, false},
+		{"err", &Buffer{loader: func(context.Context) ([]uint8, error) { return nil, errors.New("") }}, nil, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+			for i := 0; i < 2; i++ {
+				got, err := tt.b.Load()
+				if (err != nil) != tt.wantErr {
+					t.Errorf("Buffer.Load() error = %v, wantErr %v", err, tt.wantErr)
+					return
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Buffer.Load() = %v, want %v", got, tt.want)
+				}
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Buffer.Load() loaded %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
 func TestImage_IsEmbeddedResource(t *testing.T) {
 	tests := []struct {
 		name string
@@ -49,6 +90,7 @@ func TestImage_IsEmbeddedResource(t *testing.T) {
 	}{
 		{"png", &Image{URI: "data:image/png;base64,dsjdsaGGUDXGA"}, true},
 		{"jpg", &Image{URI: "data:image/png;base64,dsjdsaGGUDXGA"}, true},
+		{"other", &Image{URI: "data:image/ktx2;base64,dsjdsaGGUDXGA"}, true},
 		{"external", &Image{URI: "https://web.com/a"}, false},
 	}
 	for _, tt := range tests {
@@ -60,6 +102,25 @@ func TestImage_IsEmbeddedResource(t *testing.T) {
 	}
 }
 
+func TestImage_EmbeddedResource(t *testing.T) {
+	tests := []struct {
+		name string
+		im   *Image
+		want string
+	}{
+		{"mimeType", &Image{MimeType: "image/ktx2", Data: []uint8{1, 2, 3}}, "data:image/ktx2;base64,AQID"},
+		{"detected", &Image{Data: []uint8("\x89PNG\x0D\x0A\x1A\x0A")}, "data:image/png;base64,iVBORw0KGgo="},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.im.EmbeddedResource()
+			if got := tt.im.URI; got != tt.want {
+				t.Errorf("Image.EmbeddedResource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestImage_MarshalData(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -72,6 +133,7 @@ func TestImage_MarshalData(t *testing.T) {
 		{"empty", &Image{URI: "data:image/png;base64,"}, []uint8{}, false},
 		{"empty", &Image{URI: "data:image/jpeg;base64,"}, []uint8{}, false},
 		{"test", &Image{URI: "data:image/png;base64,TEST"}, []uint8{76, 68, 147}, false},
+		{"percentEncoded", &Image{URI: "data:image/png,%01%02"}, []uint8{1, 2}, false},
 		{"complex", &Image{URI: "data:image/png;base64,YW55IGNhcm5hbCBwbGVhcw=="}, []uint8{97, 110, 121, 32, 99, 97, 114, 110, 97, 108, 32, 112, 108, 101, 97, 115}, false},
 	}
 	for _, tt := range tests {
@@ -663,6 +725,16 @@ func TestNode_MarshalJSON(t *testing.T) {
 			Skin:        1,
 			Mesh:        1,
 		}, []byte(`{"camera":1,"skin":1,"matrix":[1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0],"mesh":1,"rotation":[1,0,0,0],"scale":[1,0,0],"translation":[1,0,0]}`), false},
+		{"extras", &Node{
+			Name:     `"scale":[1,1,1],,"mesh":-1`,
+			Matrix:   [16]float64{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1},
+			Rotation: [4]float64{0, 0, 0, 1},
+			Scale:    [3]float64{2, 2, 2},
+			Camera:   -1,
+			Skin:     -1,
+			Mesh:     -1,
+			Extras:   map[string]interface{}{"camera": -1, "mesh": -1, "scale": []float64{1, 1, 1}, "translation": []float64{0, 0, 0}},
+		}, []byte(`{"scale":[2,2,2],"extras":{"camera":-1,"mesh":-1,"scale":[1,1,1],"translation":[0,0,0]},"name":"\"scale\":[1,1,1],,\"mesh\":-1"}`), false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -688,6 +760,7 @@ func TestSkin_MarshalJSON(t *testing.T) {
 		{"default", &Skin{InverseBindMatrices: -1, Skeleton: -1}, []byte(`{"joints":null}`), false},
 		{"empty", &Skin{InverseBindMatrices: 0, Skeleton: 0}, []byte(`{"inverseBindMatrices":0,"skeleton":0,"joints":null}`), false},
 		{"nodefault", &Skin{InverseBindMatrices: 1, Skeleton: 2}, []byte(`{"inverseBindMatrices":1,"skeleton":2,"joints":null}`), false},
+		{"extras", &Skin{InverseBindMatrices: -1, Skeleton: 1, Name: "a,,b", Extras: map[string]interface{}{"inverseBindMatrices": -1}}, []byte(`{"skeleton":1,"extras":{"inverseBindMatrices":-1},"name":"a,,b","joints":null}`), false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -738,6 +811,7 @@ func TestMaterial_MarshalJSON(t *testing.T) {
 		{"default", &Material{AlphaCutoff: 0.5, AlphaMode: Opaque}, []byte(`{}`), false},
 		{"empty", &Material{AlphaCutoff: 0, AlphaMode: Blend}, []byte(`{"alphaMode":"BLEND","alphaCutoff":0}`), false},
 		{"nodefault", &Material{AlphaCutoff: 1, AlphaMode: Blend}, []byte(`{"alphaMode":"BLEND","alphaCutoff":1}`), false},
+		{"extras", &Material{AlphaCutoff: 0.5, AlphaMode: Opaque, Name: "{,}", Extras: map[string]interface{}{"alphaCutoff": 0.5, "alphaMode": "OPAQUE", "emissiveFactor": []float64{0, 0, 0}}}, []byte(`{"extras":{"alphaCutoff":0.5,"alphaMode":"OPAQUE","emissiveFactor":[0,0,0]},"name":"{,}"}`), false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -813,6 +887,7 @@ func TestPBRMetallicRoughness_MarshalJSON(t *testing.T) {
 		{"default", &PBRMetallicRoughness{MetallicFactor: 1, RoughnessFactor: 1, BaseColorFactor: [4]float64{1, 1, 1, 1}}, []byte(`{}`), false},
 		{"empty", &PBRMetallicRoughness{MetallicFactor: 0, RoughnessFactor: 0, BaseColorFactor: [4]float64{0, 0, 0, 0}}, []byte(`{"baseColorFactor":[0,0,0,0],"metallicFactor":0,"roughnessFactor":0}`), false},
 		{"nodefault", &PBRMetallicRoughness{MetallicFactor: 0.5, RoughnessFactor: 0.5, BaseColorFactor: [4]float64{1, 0.5, 1, 1}}, []byte(`{"baseColorFactor":[1,0.5,1,1],"metallicFactor":0.5,"roughnessFactor":0.5}`), false},
+		{"extras", &PBRMetallicRoughness{MetallicFactor: 1, RoughnessFactor: 0.5, BaseColorFactor: [4]float64{1, 1, 1, 1}, Extras: map[string]interface{}{"metallicFactor": 1, "baseColorFactor": []float64{1, 1, 1, 1}}}, []byte(`{"roughnessFactor":0.5,"extras":{"baseColorFactor":[1,1,1,1],"metallicFactor":1}}`), false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -888,6 +963,7 @@ func TestTexture_MarshalJSON(t *testing.T) {
 		{"default", &Texture{Sampler: -1, Source: -1}, []byte(`{}`), false},
 		{"empty", &Texture{Sampler: 0, Source: 0}, []byte(`{"sampler":0,"source":0}`), false},
 		{"nodefault", &Texture{Sampler: 1, Source: 1}, []byte(`{"sampler":1,"source":1}`), false},
+		{"extras", &Texture{Sampler: -1, Source: 1, Name: "a,,b", Extras: `"sampler":-1`}, []byte(`{"source":1,"extras":"\"sampler\":-1","name":"a,,b"}`), false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
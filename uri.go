@@ -0,0 +1,86 @@
+package gltf
+
+import (
+	"errors"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// resolveURI returns the slash-separated path, relative to the document directory,
+// of the resource identified by the relative URI reference uri, as defined by RFC 3986.
+// The path is percent-decoded, dot segments are removed and the query and fragment are dropped.
+// It fails if uri is absolute or if the path leaves the document directory.
+func resolveURI(uri string) (string, error) {
+	p, err := uriPath(uri)
+	if err != nil {
+		return "", err
+	}
+	// Backslashes are separators on Windows, so they cannot be used to escape either.
+	if clean := path.Clean(strings.ReplaceAll(p, "\\", "/")); clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", errors.New("gltf: URI leaves the document directory")
+	}
+	return p, nil
+}
+
+// errRemoteURI is returned by resolveURI and uriPath for the http and https URIs,
+// which cannot be read from a file system.
+var errRemoteURI = errors.New("gltf: URI is remote")
+
+// uriPath is like resolveURI but the path can start with dot-dot segments.
+func uriPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "http" || u.Scheme == "https" {
+		return "", errRemoteURI
+	}
+	if u.Scheme != "" || u.Host != "" || u.Opaque != "" {
+		return "", errors.New("gltf: URI is not relative")
+	}
+	if u.Path == "" || strings.HasPrefix(u.Path, "/") || strings.HasPrefix(u.Path, "\\") {
+		return "", errors.New("gltf: URI has no relative path")
+	}
+	return path.Clean(u.Path), nil
+}
+
+// validateBufferURI checks that uri is either an http, https or data URI, which are resolved as is,
+// or a reference that does not leave the document directory.
+// The URIs with any other scheme are up to the callback to resolve,
+// so they cannot have dot-dot segments either.
+func validateBufferURI(path, uri string) error {
+	u, err := url.Parse(uri)
+	if uri == "" || err != nil {
+		return &URIError{Path: path, URI: uri, Err: err}
+	}
+	switch u.Scheme {
+	case "http", "https", "data":
+		return nil
+	case "":
+		_, err = resolveURI(uri)
+	default:
+		err = checkDotSegments(u)
+	}
+	if err != nil {
+		return &URIError{Path: path, URI: uri, Err: err}
+	}
+	return nil
+}
+
+// checkDotSegments fails if the host or the percent-decoded path of u has a dot-dot segment.
+func checkDotSegments(u *url.URL) error {
+	p := u.Path
+	if u.Opaque != "" {
+		var err error
+		if p, err = url.PathUnescape(u.Opaque); err != nil {
+			return err
+		}
+	}
+	for _, segment := range strings.Split(strings.ReplaceAll(u.Host+"/"+p, "\\", "/"), "/") {
+		if segment == ".." {
+			return errors.New("gltf: URI has a dot-dot segment")
+		}
+	}
+	return nil
+}
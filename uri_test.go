@@ -0,0 +1,123 @@
+package gltf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestResolveURI(t *testing.T) {
+	tests := []struct {
+		uri     string
+		want    string
+		wantErr bool
+	}{
+		{"a.bin", "a.bin", false},
+		{"a..b.bin", "a..b.bin", false},
+		{"my%20model.bin", "my model.bin", false},
+		{"my model.bin", "my model.bin", false},
+		{"./sub/dir/a.bin", "sub/dir/a.bin", false},
+		{"sub/../a.bin", "a.bin", false},
+		{"a.bin?v=2#frag", "a.bin", false},
+		{"%2E%2E/a.bin", "", true},
+		{"../a.bin", "", true},
+		{"sub/../../a.bin", "", true},
+		{"..", "", true},
+		{"..\\a.bin", "", true},
+		{"/a.bin", "", true},
+		{"\\a.bin", "", true},
+		{"//host/a.bin", "", true},
+		{"http://web.com/a.bin", "", true},
+		{"C:/a.bin", "", true},
+		{"a%zz.bin", "", true},
+		{"?v=2", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			got, err := resolveURI(tt.uri)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("resolveURI() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("resolveURI() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateBufferURI(t *testing.T) {
+	tests := []struct {
+		uri     string
+		wantErr bool
+	}{
+		{"a.bin", false},
+		{"a..b.bin", false},
+		{"my%20model.bin", false},
+		{"https://web.com/a.bin", false},
+		{"HTTP://web.com/a/../b.bin", false},
+		{"data:application/octet-stream;base64,AQID", false},
+		{"s3://bucket/a.bin", false},
+		{"C:/a.bin", false},
+		{"x:/../../../etc/passwd", true},
+		{"x:../a.bin", true},
+		{"x:a/%2E%2E/%2E%2E/b.bin", true},
+		{"x:/a\\..\\..\\b.bin", true},
+		{"x://../a.bin", true},
+		{"file:///tmp/../etc/passwd", true},
+		{"", true},
+		{"../a.bin", true},
+		{"/a.bin", true},
+		{"a%zz.bin", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			if err := validateBufferURI("buffers[0]", tt.uri); (err != nil) != tt.wantErr {
+				t.Errorf("validateBufferURI() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestURI_schemeTraversal(t *testing.T) {
+	for _, uri := range []string{"x:/../../../etc/passwd", "x:/../../../tmp/evil"} {
+		t.Run(uri, func(t *testing.T) {
+			var called bool
+			rcb := func(string) (io.ReadCloser, error) {
+				called = true
+				return io.NopCloser(strings.NewReader("abc")), nil
+			}
+			var uriErr *URIError
+			doc := `{"asset":{"version":"2.0"},"buffers":[{"byteLength":3,"uri":"` + uri + `"}]}`
+			if err := NewDecoder(strings.NewReader(doc), rcb).Decode(new(Document)); !errors.As(err, &uriErr) {
+				t.Errorf("Decoder.Decode() buffer error = %v, want *URIError", err)
+			}
+			doc = `{"asset":{"version":"2.0"},"images":[{"uri":"` + uri + `"}]}`
+			if err := NewDecoder(strings.NewReader(doc), rcb).Decode(new(Document)); !errors.As(err, &uriErr) {
+				t.Errorf("Decoder.Decode() image error = %v, want *URIError", err)
+			}
+			if called {
+				t.Error("Decoder.Decode() called the read callback")
+			}
+
+			wcb := func(string, int) (io.WriteCloser, error) {
+				called = true
+				return &writeCloser{io.Discard}, nil
+			}
+			out := &Document{Buffers: []Buffer{{ByteLength: 3, URI: uri, Data: []uint8{1, 2, 3}}}}
+			if err := NewEncoder(new(bytes.Buffer), wcb, false).Encode(out); !errors.As(err, &uriErr) {
+				t.Errorf("Encoder.Encode() buffer error = %v, want *URIError", err)
+			}
+			out = &Document{Images: []Image{{URI: uri, Data: []uint8{1, 2, 3}}}}
+			if err := NewEncoder(new(bytes.Buffer), wcb, false).Encode(out); !errors.As(err, &uriErr) {
+				t.Errorf("Encoder.Encode() image error = %v, want *URIError", err)
+			}
+			if called {
+				t.Error("Encoder.Encode() called the write callback")
+			}
+		})
+	}
+}
@@ -1,20 +1,46 @@
 package gltf
 
 import (
+	"reflect"
+	"strings"
+
 	val "github.com/go-playground/validator"
 )
 
 // Validate ensures that a document follows the glTF 2.0 specs.
+// The returned error, if any, is of type ValidationErrors.
 func (d *Document) Validate() error {
 	validate := val.New()
+	validate.RegisterTagNameFunc(jsonTagName)
 	validate.RegisterStructValidation(imageValidation, Image{})
-	return validate.Struct(d)
+	err := validate.Struct(d)
+	if errs, ok := err.(val.ValidationErrors); ok {
+		verrs := make(ValidationErrors, len(errs))
+		for i, e := range errs {
+			// The namespace starts with the struct name, which is not part of the JSON path.
+			path := e.Namespace()
+			if i := strings.IndexByte(path, '.'); i >= 0 {
+				path = path[i+1:]
+			}
+			verrs[i] = &ValidationError{Path: path, Namespace: e.StructNamespace(), Tag: e.Tag(), Value: e.Value()}
+		}
+		return verrs
+	}
+	return err
+}
+
+func jsonTagName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
 }
 
 func imageValidation(sl val.StructLevel) {
 	image := sl.Current().Interface().(Image)
 
 	if image.URI == "" && image.MimeType == "" {
-		sl.ReportError(image.MimeType, "MimeType", "mimeType", "", "")
+		sl.ReportError(image.MimeType, "mimeType", "MimeType", "required", "")
 	}
 }
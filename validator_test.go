@@ -1,9 +1,8 @@
 package gltf
 
 import (
+	"errors"
 	"testing"
-
-	val "github.com/go-playground/validator"
 )
 
 func TestValidateDocument(t *testing.T) {
@@ -120,7 +119,7 @@ func TestValidateDocument(t *testing.T) {
 				return
 			}
 			if tt.wantErr {
-				errVal := err.(val.ValidationErrors)[0].Namespace()
+				errVal := err.(ValidationErrors)[0].Namespace
 				if errVal != tt.name {
 					t.Errorf("Document.Validate() error = %v, wantErr %v", errVal, tt.name)
 				}
@@ -128,3 +127,17 @@ func TestValidateDocument(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateDocument_errorPath(t *testing.T) {
+	doc := &Document{Asset: Asset{Version: "1.0"}, Accessors: []Accessor{{ComponentType: 1, Count: 1, Type: "SCALAR"}}}
+	var errs ValidationErrors
+	if !errors.As(doc.Validate(), &errs) {
+		t.Fatalf("Document.Validate() error is not ValidationErrors")
+	}
+	if got := errs[0].Path; got != "accessors[0].componentType" {
+		t.Errorf("Document.Validate() path = %v, want accessors[0].componentType", got)
+	}
+	if got := errs[0].Tag; got != "oneof" {
+		t.Errorf("Document.Validate() tag = %v, want oneof", got)
+	}
+}
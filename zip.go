@@ -0,0 +1,88 @@
+package gltf
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// OpenZip will open the zip archive specified by name and return the Document packaged in it.
+func OpenZip(name string) (*Document, error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return DecodeZip(&zr.Reader)
+}
+
+// DecodeZip locates the root glTF or GLB entry of the zip archive and decodes it.
+// External resources are read from the sibling entries of the archive.
+// The root entry is the .gltf or .glb file closest to the archive root, which must be unique.
+func DecodeZip(zr *zip.Reader) (*Document, error) {
+	name, err := zipRootEntry(zr)
+	if err != nil {
+		return nil, err
+	}
+	return OpenFS(zr, name)
+}
+
+// SaveZip will save a document as a glTF or a GLB entry specified by name into a zip archive written to w.
+// External buffers and images are stored as entries of the same archive.
+func SaveZip(w io.Writer, doc *Document, name string, asBinary bool) error {
+	zw := zip.NewWriter(w)
+	if err := SaveFS(zipWriteFS{zw}, doc, name, asBinary); err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+type zipWriteFS struct {
+	zw *zip.Writer
+}
+
+func (z zipWriteFS) Create(name string) (io.WriteCloser, error) {
+	w, err := z.zw.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return nopWriteCloser{w}, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func zipRootEntry(zr *zip.Reader) (string, error) {
+	var root string
+	rootDepth := -1
+	ambiguous := false
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+		ext := strings.ToLower(path.Ext(f.Name))
+		if ext != ".gltf" && ext != ".glb" {
+			continue
+		}
+		depth := strings.Count(f.Name, "/")
+		switch {
+		case rootDepth == -1 || depth < rootDepth:
+			root, rootDepth, ambiguous = f.Name, depth, false
+		case depth == rootDepth:
+			ambiguous = true
+		}
+	}
+	if rootDepth == -1 {
+		return "", errors.New("gltf: zip archive does not contain a glTF or GLB file")
+	}
+	if ambiguous {
+		return "", errors.New("gltf: zip archive contains more than one root glTF or GLB file")
+	}
+	return root, nil
+}
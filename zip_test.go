@@ -0,0 +1,103 @@
+package gltf
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func newZip(t *testing.T, files map[string][]byte) *zip.Reader {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zr
+}
+
+func TestDecodeZip(t *testing.T) {
+	doc := []byte(`{"asset":{"version":"2.0"},"buffers":[{"byteLength":3,"uri":"a.bin"}]}`)
+	tests := []struct {
+		name    string
+		files   map[string][]byte
+		want    *Document
+		wantErr bool
+	}{
+		{"empty", map[string][]byte{"a.bin": {1, 2, 3}}, nil, true},
+		{"ambiguous", map[string][]byte{"a.gltf": doc, "b.glb": doc, "a.bin": {1, 2, 3}}, nil, true},
+		{"missingResource", map[string][]byte{"a.gltf": doc}, nil, true},
+		{"base", map[string][]byte{"a.gltf": doc, "a.bin": {1, 2, 3}}, &Document{
+			Asset: Asset{Version: "2.0"}, Scene: -1, Buffers: []Buffer{{ByteLength: 3, URI: "a.bin", Data: []uint8{1, 2, 3}}},
+		}, false},
+		{"nested", map[string][]byte{"model/a.GLTF": doc, "model/a.bin": {1, 2, 3}, "model/lod/b.gltf": doc, "__MACOSX/c.gltf": doc}, &Document{
+			Asset: Asset{Version: "2.0"}, Scene: -1, Buffers: []Buffer{{ByteLength: 3, URI: "a.bin", Data: []uint8{1, 2, 3}}},
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeZip(newZip(t, tt.files))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DecodeZip() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Errorf("DecodeZip() = %v", diff)
+			}
+		})
+	}
+}
+
+func TestSaveZip(t *testing.T) {
+	for _, asBinary := range []bool{false, true} {
+		t.Run(fmt.Sprintf("binary_%t", asBinary), func(t *testing.T) {
+			doc, err := Open("testdata/Cube/glTF/Cube.gltf")
+			if err != nil {
+				t.Fatal(err)
+			}
+			dir, err := ioutil.TempDir("", "gltf")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			name := filepath.Join(dir, "cube.zip")
+			f, err := os.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = SaveZip(f, doc, "cube/Cube.gltf", asBinary)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				t.Fatalf("SaveZip() error = %v", err)
+			}
+			got, err := OpenZip(name)
+			if err != nil {
+				t.Fatalf("OpenZip() error = %v", err)
+			}
+			if diff := deep.Equal(got, doc); diff != nil {
+				t.Errorf("SaveZip() = %v", diff)
+			}
+		})
+	}
+}